@@ -2,9 +2,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,6 +19,9 @@ import (
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/migrations"
 )
 
+// shutdownTimeout bounds how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
 func createDBIfNotExists() error {
 	// Connect to default postgres database first
 	dbHost := os.Getenv("DB_HOST")
@@ -90,9 +99,43 @@ func main() {
 	// Setup routes
 	container.SetupRoutes()
 
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start delivering queued emails in the background. The dispatcher gets
+	// its own context so a batch that is in flight when the signal arrives
+	// can still record its results, Stop below ends it
+	container.EmailDispatcher.Start(context.Background())
+
 	// Start the server
-	container.Logger.Info("Starting auth service", container.Logger.Field("port", container.Config.Server.Port))
-	if err := container.Router.Run(fmt.Sprintf(":%s", container.Config.Server.Port)); err != nil {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", container.Config.Server.Port),
+		Handler: container.Router,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		container.Logger.Info("Starting auth service", container.Logger.Field("port", container.Config.Server.Port))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		container.EmailDispatcher.Stop()
 		container.Logger.Fatal("Failed to start server", container.Logger.Field("error", err.Error()))
+	case <-ctx.Done():
+	}
+
+	// Let in-flight requests finish before stopping the dispatcher, they may
+	// still queue emails
+	container.Logger.Info("Shutting down auth service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		container.Logger.Error("Failed to shut down server gracefully", container.Logger.Field("error", err.Error()))
 	}
+	container.EmailDispatcher.Stop()
+	container.Logger.Info("Auth service stopped")
 }
@@ -0,0 +1,276 @@
+// cmd/keyctl/main.go
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+)
+
+const usage = `keyctl manages the JWT signing keys listed in a key manifest (JWT_KEY_MANIFEST).
+
+Usage:
+  keyctl [-manifest path] <command> [flags]
+
+Commands:
+  generate  Create a key pair and add it to the manifest, as active if there is no active key yet, otherwise as next
+  promote   Make a next key the signing key and retire the current one
+  prune     Remove retired keys whose tokens have all expired
+  list      Show the keys in the manifest
+
+Rotation:
+  1. keyctl generate, then roll out the manifest so every instance publishes the new key
+  2. once verifiers have refreshed their JWKS, keyctl promote -kid <kid> and roll out again
+  3. after the longest token lifetime has passed, keyctl prune and roll out
+`
+
+func main() {
+	manifestPath := flag.String("manifest", "jwt-keys.json", "Path of the key manifest")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch flag.Arg(0) {
+	case "generate":
+		err = generate(*manifestPath, flag.Args()[1:])
+	case "promote":
+		err = promote(*manifestPath, flag.Args()[1:])
+	case "prune":
+		err = prune(*manifestPath, flag.Args()[1:])
+	case "list":
+		err = list(*manifestPath)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "keyctl:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(manifestPath string, args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	algorithm := flags.String("alg", service.JWTAlgorithmES256, "Signing algorithm: RS256, ES256 or EdDSA")
+	keyID := flags.String("kid", "", "Key ID (default: generated from the current date)")
+	flags.Parse(args)
+
+	manifest, err := loadOrCreateManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if *keyID == "" {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return err
+		}
+		*keyID = fmt.Sprintf("auth-key-%s-%s", time.Now().UTC().Format("20060102"), hex.EncodeToString(suffix))
+	}
+
+	private, public, err := generateKeyPair(*algorithm)
+	if err != nil {
+		return err
+	}
+
+	privateFile := *keyID + ".pem"
+	publicFile := *keyID + ".pub.pem"
+	if err := writePEMFiles(filepath.Dir(manifestPath), privateFile, publicFile, private, public); err != nil {
+		return err
+	}
+
+	if err := manifest.Add(service.JWTKeyEntry{
+		KeyID:          *keyID,
+		Algorithm:      *algorithm,
+		PrivateKeyFile: privateFile,
+		PublicKeyFile:  publicFile,
+	}); err != nil {
+		return err
+	}
+	if err := service.SaveJWTKeyManifest(manifestPath, manifest); err != nil {
+		return err
+	}
+
+	entry := manifest.Keys[len(manifest.Keys)-1]
+	fmt.Printf("Added %s key %s as %s\n", entry.Algorithm, entry.KeyID, entry.Status)
+	return nil
+}
+
+func promote(manifestPath string, args []string) error {
+	flags := flag.NewFlagSet("promote", flag.ExitOnError)
+	keyID := flags.String("kid", "", "Key ID of the next key to promote")
+	flags.Parse(args)
+
+	if *keyID == "" {
+		return fmt.Errorf("-kid is required")
+	}
+
+	manifest, err := service.LoadJWTKeyManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	previous := manifest.Active()
+	var previousID string
+	if previous != nil {
+		previousID = previous.KeyID
+	}
+
+	if err := manifest.Promote(*keyID); err != nil {
+		return err
+	}
+	if err := service.SaveJWTKeyManifest(manifestPath, manifest); err != nil {
+		return err
+	}
+
+	fmt.Printf("Promoted %s, retired %s\n", *keyID, previousID)
+	return nil
+}
+
+func prune(manifestPath string, args []string) error {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	maxTokenLifetime := flags.Duration("max-token-lifetime", 24*time.Hour,
+		"Longest lifetime of any token, the refresh token expiry (REFRESH_TOKEN_EXPIRY_HOURS)")
+	flags.Parse(args)
+
+	manifest, err := service.LoadJWTKeyManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	removed := manifest.Prune(*maxTokenLifetime)
+	if len(removed) == 0 {
+		fmt.Println("No retired keys to prune")
+		return nil
+	}
+	if err := service.SaveJWTKeyManifest(manifestPath, manifest); err != nil {
+		return err
+	}
+
+	// The key files are no longer referenced, destroy the private keys
+	dir := filepath.Dir(manifestPath)
+	for _, entry := range removed {
+		for _, file := range []string{entry.PrivateKeyFile, entry.PublicKeyFile} {
+			if file == "" {
+				continue
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		fmt.Printf("Pruned %s, retired %s\n", entry.KeyID, entry.RetiredAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func list(manifestPath string) error {
+	manifest, err := service.LoadJWTKeyManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALG\tSTATUS\tCREATED\tPROMOTED\tRETIRED")
+	for _, entry := range manifest.Keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.KeyID, entry.Algorithm, entry.Status,
+			entry.CreatedAt.Format(time.RFC3339), formatTime(entry.PromotedAt), formatTime(entry.RetiredAt))
+	}
+	return w.Flush()
+}
+
+// loadOrCreateManifest loads the manifest, or starts an empty one if the
+// file doesn't exist yet
+func loadOrCreateManifest(manifestPath string) (*service.JWTKeyManifest, error) {
+	manifest, err := service.LoadJWTKeyManifest(manifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &service.JWTKeyManifest{}, nil
+	}
+	return manifest, err
+}
+
+func generateKeyPair(algorithm string) (crypto.PrivateKey, crypto.PublicKey, error) {
+	switch algorithm {
+	case service.JWTAlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 3072)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	case service.JWTAlgorithmES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &key.PublicKey, nil
+	case service.JWTAlgorithmEdDSA:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return private, public, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported algorithm %q, use RS256, ES256 or EdDSA", algorithm)
+	}
+}
+
+// writePEMFiles writes the private key as PKCS#8 and the public key as PKIX,
+// refusing to overwrite existing files
+func writePEMFiles(dir, privateFile, publicFile string, private crypto.PrivateKey, public crypto.PublicKey) error {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return err
+	}
+
+	if err := writeNewFile(filepath.Join(dir, privateFile), &pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}, 0o600); err != nil {
+		return err
+	}
+	return writeNewFile(filepath.Join(dir, publicFile), &pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}, 0o644)
+}
+
+func writeNewFile(path string, block *pem.Block, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, block); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -2,8 +2,11 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strconv"
@@ -12,6 +15,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/hkdf"
 )
 
 // ValidationError represents a configuration validation error
@@ -29,12 +33,14 @@ type Config struct {
 	Server       ServerConfig
 	Database     DatabaseConfig
 	Email        EmailConfig
+	SMS          SMSConfig
 	OTP          OTPConfig
 	Security     SecurityConfig
 	RateLimiting RateLimitingConfig
 	Logging      LoggingConfig
 	Redis        RedisConfig
 	JWT          JWTConfig
+	I18n         I18nConfig
 }
 
 // Validate checks if the configuration is valid
@@ -54,6 +60,11 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	// Validate SMS config
+	if err := c.SMS.Validate(); err != nil {
+		return err
+	}
+
 	// Validate OTP config
 	if err := c.OTP.Validate(); err != nil {
 		return err
@@ -86,6 +97,8 @@ func (c *Config) Validate() error {
 type ServerConfig struct {
 	Port              string
 	RequestTimeoutSec int
+	PublicBaseURL     string // URL clients reach the service at, used in the discovery document and as the default token issuer
+	IsDevelopment     bool
 }
 
 // Validate checks if server configuration is valid
@@ -98,6 +111,19 @@ func (c *ServerConfig) Validate() error {
 		return &ValidationError{Field: "Server.RequestTimeoutSec", Message: "must be greater than 0"}
 	}
 
+	// The discovery document tells relying parties where to fetch signing
+	// keys, so outside development it must never depend on the request
+	if c.PublicBaseURL == "" && !c.IsDevelopment {
+		return &ValidationError{Field: "Server.PublicBaseURL", Message: "cannot be empty outside development"}
+	}
+
+	if c.PublicBaseURL != "" {
+		u, err := url.Parse(c.PublicBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &ValidationError{Field: "Server.PublicBaseURL", Message: "must be an absolute http or https URL"}
+		}
+	}
+
 	return nil
 }
 
@@ -120,6 +146,32 @@ type EmailConfig struct {
 	FromName      string
 	OTPExpiryMins int
 	IsDevelopment bool
+
+	// SMTP delivery settings
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPSecurity           string // "starttls", "tls" (implicit) or "none"
+	SMTPTimeoutSec         int
+	SMTPInsecureSkipVerify bool
+
+	// TemplateDir overrides the embedded email templates when set
+	TemplateDir string
+
+	// Outbox dispatcher settings
+	OutboxPollIntervalSec int
+	OutboxBatchSize       int
+	OutboxMaxAttempts     int
+	OutboxBaseBackoffSec  int
+	OutboxMaxBackoffSec   int
+	OutboxPayloadKey      string // Hex-encoded AES-256 key for the queued template data
+
+	// Development mailbox, on by default in development and refused
+	// outside it since it serves every OTP to anyone who asks
+	DevMailboxEnabled     bool
+	DevMailboxDir         string
+	DevMailboxMaxMessages int
 }
 
 // Validate checks if email configuration is valid
@@ -140,13 +192,89 @@ func (c *EmailConfig) Validate() error {
 		return &ValidationError{Field: "Email.OTPExpiryMins", Message: "must be greater than 0"}
 	}
 
+	if c.DevMailboxEnabled && !c.IsDevelopment {
+		return &ValidationError{Field: "Email.DevMailboxEnabled", Message: "can only be enabled in development"}
+	}
+
+	// Mail is never dropped: it goes to an SMTP server or, in development,
+	// at least to the mailbox
+	if c.SMTPHost == "" && !c.DevMailboxEnabled {
+		if !c.IsDevelopment {
+			return &ValidationError{Field: "Email.SMTPHost", Message: "cannot be empty outside development"}
+		}
+		return &ValidationError{Field: "Email.SMTPHost", Message: "cannot be empty with the development mailbox disabled"}
+	}
+
+	if c.OutboxPollIntervalSec <= 0 {
+		return &ValidationError{Field: "Email.OutboxPollIntervalSec", Message: "must be greater than 0"}
+	}
+
+	if c.OutboxBatchSize <= 0 {
+		return &ValidationError{Field: "Email.OutboxBatchSize", Message: "must be greater than 0"}
+	}
+
+	if c.OutboxMaxAttempts <= 0 {
+		return &ValidationError{Field: "Email.OutboxMaxAttempts", Message: "must be greater than 0"}
+	}
+
+	if c.OutboxBaseBackoffSec <= 0 || c.OutboxMaxBackoffSec < c.OutboxBaseBackoffSec {
+		return &ValidationError{Field: "Email.OutboxBaseBackoffSec", Message: "must be greater than 0 and not above OutboxMaxBackoffSec"}
+	}
+
+	// Queued emails may hold one-time codes, they are encrypted at rest.
+	// Development derives a key from JWT_SECRET when none is set.
+	if c.OutboxPayloadKey == "" {
+		if !c.IsDevelopment {
+			return &ValidationError{Field: "Email.OutboxPayloadKey", Message: "cannot be empty outside development"}
+		}
+		return &ValidationError{Field: "Email.OutboxPayloadKey", Message: "cannot be empty without JWT_SECRET"}
+	}
+	if key, err := hex.DecodeString(c.OutboxPayloadKey); err != nil || len(key) != 32 {
+		return &ValidationError{Field: "Email.OutboxPayloadKey", Message: "must be 64 hex characters"}
+	}
+
+	if c.SMTPHost != "" {
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			return &ValidationError{Field: "Email.SMTPPort", Message: "must be between 1 and 65535"}
+		}
+
+		switch strings.ToLower(c.SMTPSecurity) {
+		case "starttls", "tls", "none":
+		default:
+			return &ValidationError{Field: "Email.SMTPSecurity", Message: "must be one of starttls, tls or none"}
+		}
+
+		if c.SMTPTimeoutSec <= 0 {
+			return &ValidationError{Field: "Email.SMTPTimeoutSec", Message: "must be greater than 0"}
+		}
+	}
+
 	return nil
 }
 
 // OTPConfig holds OTP service configuration
 type OTPConfig struct {
-	Length     int
-	ExpiryMins int
+	Length            int
+	ExpiryMins        int
+	HMACSecret        string // Key for the OTP digests kept in Redis
+	IsDevelopment     bool
+	MaxAttempts       int
+	ResendCooldownSec int
+	ResendDailyLimit  int
+
+	// Purposes holds the policy of every OTP purpose, keyed by purpose name.
+	// Each falls back to Length, ExpiryMins and MaxAttempts.
+	Purposes map[string]OTPPolicyConfig
+}
+
+// OTPPurposes lists the purposes OTPs can be issued for
+var OTPPurposes = []string{"registration", "password_reset", "email_change", "login", "phone_verification"}
+
+// OTPPolicyConfig holds the OTP policy of a single purpose
+type OTPPolicyConfig struct {
+	Length      int
+	ExpiryMins  int
+	MaxAttempts int
 }
 
 // Validate checks if OTP configuration is valid
@@ -159,23 +287,140 @@ func (c *OTPConfig) Validate() error {
 		return &ValidationError{Field: "OTP.ExpiryMins", Message: "must be greater than 0"}
 	}
 
+	// Development derives a key from JWT_SECRET when none is set
+	if c.HMACSecret == "" {
+		if !c.IsDevelopment {
+			return &ValidationError{Field: "OTP.HMACSecret", Message: "cannot be empty outside development"}
+		}
+		return &ValidationError{Field: "OTP.HMACSecret", Message: "cannot be empty without JWT_SECRET"}
+	}
+
+	if c.MaxAttempts <= 0 {
+		return &ValidationError{Field: "OTP.MaxAttempts", Message: "must be greater than 0"}
+	}
+
+	if c.ResendCooldownSec <= 0 {
+		return &ValidationError{Field: "OTP.ResendCooldownSec", Message: "must be greater than 0"}
+	}
+
+	for _, purpose := range OTPPurposes {
+		policy, ok := c.Purposes[purpose]
+		if !ok {
+			return &ValidationError{Field: "OTP.Purposes." + purpose, Message: "is missing"}
+		}
+		if policy.Length <= 0 {
+			return &ValidationError{Field: "OTP.Purposes." + purpose + ".Length", Message: "must be greater than 0"}
+		}
+		if policy.ExpiryMins <= 0 {
+			return &ValidationError{Field: "OTP.Purposes." + purpose + ".ExpiryMins", Message: "must be greater than 0"}
+		}
+		if policy.MaxAttempts <= 0 {
+			return &ValidationError{Field: "OTP.Purposes." + purpose + ".MaxAttempts", Message: "must be greater than 0"}
+		}
+	}
+
+	if c.ResendDailyLimit <= 0 {
+		return &ValidationError{Field: "OTP.ResendDailyLimit", Message: "must be greater than 0"}
+	}
+
+	return nil
+}
+
+// SMSConfig holds SMS provider configuration
+type SMSConfig struct {
+	Provider      string // "console" (development stand-in) or "http"
+	IsDevelopment bool
+
+	// Console provider: optional file messages are appended to
+	FilePath string
+
+	// HTTP gateway provider
+	GatewayURL    string
+	GatewayAPIKey string
+	SenderID      string
+	TimeoutSec    int
+}
+
+// Validate checks if SMS configuration is valid
+func (c *SMSConfig) Validate() error {
+	switch c.Provider {
+	case "console":
+		if !c.IsDevelopment {
+			return &ValidationError{Field: "SMS.Provider", Message: "console provider is only allowed in development"}
+		}
+	case "http":
+		if c.GatewayURL == "" {
+			return &ValidationError{Field: "SMS.GatewayURL", Message: "cannot be empty with the http provider"}
+		}
+		if c.TimeoutSec <= 0 {
+			return &ValidationError{Field: "SMS.TimeoutSec", Message: "must be greater than 0"}
+		}
+	default:
+		return &ValidationError{Field: "SMS.Provider", Message: "must be one of console or http"}
+	}
+
 	return nil
 }
 
 // SecurityConfig holds security-related configuration
 type SecurityConfig struct {
+	PasswordHashAlgorithm        string `mapstructure:"password_hash_algorithm"` // "argon2id" or "bcrypt"
+	Argon2MemoryKiB              int    `mapstructure:"argon2_memory_kib"`
+	Argon2Iterations             int    `mapstructure:"argon2_iterations"`
+	Argon2Parallelism            int    `mapstructure:"argon2_parallelism"`
 	BcryptCost                   int    `mapstructure:"bcrypt_cost"`
 	MinPasswordChars             int    `mapstructure:"min_password_chars"`
+	MinPasswordScore             int    `mapstructure:"min_password_score"`     // Minimum estimated strength, 0-4
+	PasswordHistoryDepth         int    `mapstructure:"password_history_depth"` // Previous passwords that can't be reused
+	BreachedPasswordDir          string `mapstructure:"breached_password_dir"`  // Empty disables the breached-password check
+	BreachedPasswordMinCount     int    `mapstructure:"breached_password_min_count"`
 	JWTSecret                    string `mapstructure:"jwt_secret"`
+	JWTSigningAlgorithm          string `mapstructure:"jwt_signing_algorithm"` // HS256, RS256, ES256 or EdDSA
+	JWTPrivateKeyFile            string `mapstructure:"jwt_private_key_file"`  // PEM private key for RS256, ES256 and EdDSA
+	JWTPublicKeyFile             string `mapstructure:"jwt_public_key_file"`   // Optional PEM public key, derived from the private key if unset
+	JWTKeyID                     string `mapstructure:"jwt_key_id"`
+	JWTKeyManifest               string `mapstructure:"jwt_key_manifest"` // Key rotation manifest, replaces the single key settings above when set
 	AccessTokenExpiryMinutes     int    `mapstructure:"access_token_expiry_minutes"`
 	RefreshTokenExpiryHours      int    `mapstructure:"refresh_token_expiry_hours"`
-	TokenIssuer                  string `mapstructure:"token_issuer"`
+	TokenIssuer                  string `mapstructure:"token_issuer"` // iss claim and discovery issuer, an https URL
 	LoginAttemptsThreshold       int    `mapstructure:"login_attempts_threshold"`
 	LoginThrottleDurationMinutes int    `mapstructure:"login_throttle_duration_minutes"`
+
+	// Client ID to secret of the services allowed to introspect tokens
+	IntrospectionClients map[string]string
+
+	// Concurrent sessions allowed per role, "*" for every other role; roles
+	// without a limit are unlimited
+	SessionLimits      map[string]int
+	SessionLimitPolicy string // "evict" the least recently used session or "reject" the login
+
+	// What to do when a refresh token is used from a different user agent
+	// family or IP subnet than at login: "ignore", "log" or "reject"
+	RefreshBindingPolicy     string
+	RefreshBindingIPv4Prefix int
+	RefreshBindingIPv6Prefix int
+
+	IsDevelopment bool
 }
 
 // Validate checks if security configuration is valid
 func (c *SecurityConfig) Validate() error {
+	switch c.PasswordHashAlgorithm {
+	case "argon2id":
+		if c.Argon2Iterations < 1 {
+			return &ValidationError{Field: "Security.Argon2Iterations", Message: "must be at least 1"}
+		}
+		if c.Argon2Parallelism < 1 || c.Argon2Parallelism > 255 {
+			return &ValidationError{Field: "Security.Argon2Parallelism", Message: "must be between 1 and 255"}
+		}
+		if c.Argon2MemoryKiB < 8*c.Argon2Parallelism {
+			return &ValidationError{Field: "Security.Argon2MemoryKiB", Message: "must be at least 8 KiB per thread"}
+		}
+	case "bcrypt":
+	default:
+		return &ValidationError{Field: "Security.PasswordHashAlgorithm", Message: "must be argon2id or bcrypt"}
+	}
+
 	if c.BcryptCost < 10 || c.BcryptCost > 31 {
 		return &ValidationError{Field: "Security.BcryptCost", Message: "must be between 10 and 31"}
 	}
@@ -184,6 +429,74 @@ func (c *SecurityConfig) Validate() error {
 		return &ValidationError{Field: "Security.MinPasswordChars", Message: "must be at least 8"}
 	}
 
+	if c.MinPasswordScore < 0 || c.MinPasswordScore > 4 {
+		return &ValidationError{Field: "Security.MinPasswordScore", Message: "must be between 0 and 4"}
+	}
+
+	// Each algorithm needs its own key source, a key manifest replaces both
+	switch c.JWTSigningAlgorithm {
+	case "HS256":
+		if c.JWTSecret == "" && c.JWTKeyManifest == "" {
+			return &ValidationError{Field: "Security.JWTSecret", Message: "is required for HS256"}
+		}
+	case "RS256", "ES256", "EdDSA":
+		if c.JWTPrivateKeyFile == "" && c.JWTKeyManifest == "" {
+			return &ValidationError{Field: "Security.JWTPrivateKeyFile", Message: "is required for " + c.JWTSigningAlgorithm}
+		}
+	default:
+		return &ValidationError{Field: "Security.JWTSigningAlgorithm", Message: "must be one of HS256, RS256, ES256 or EdDSA"}
+	}
+
+	if c.JWTKeyID == "" {
+		return &ValidationError{Field: "Security.JWTKeyID", Message: "cannot be empty"}
+	}
+
+	// OpenID Connect clients only accept an https issuer that matches the
+	// iss claim, development may use http
+	issuer, err := url.Parse(c.TokenIssuer)
+	if err != nil || issuer.Host == "" || issuer.RawQuery != "" || issuer.Fragment != "" ||
+		(issuer.Scheme != "https" && (issuer.Scheme != "http" || !c.IsDevelopment)) {
+		return &ValidationError{Field: "Security.TokenIssuer", Message: "must be an https URL without query or fragment"}
+	}
+
+	for role, limit := range c.SessionLimits {
+		if limit < 1 {
+			return &ValidationError{Field: "Security.SessionLimits", Message: "limit of " + role + " must be at least 1"}
+		}
+	}
+
+	if c.SessionLimitPolicy != "evict" && c.SessionLimitPolicy != "reject" {
+		return &ValidationError{Field: "Security.SessionLimitPolicy", Message: "must be evict or reject"}
+	}
+
+	switch c.RefreshBindingPolicy {
+	case "ignore", "log", "reject":
+	default:
+		return &ValidationError{Field: "Security.RefreshBindingPolicy", Message: "must be ignore, log or reject"}
+	}
+
+	if c.RefreshBindingIPv4Prefix < 0 || c.RefreshBindingIPv4Prefix > 32 {
+		return &ValidationError{Field: "Security.RefreshBindingIPv4Prefix", Message: "must be between 0 and 32"}
+	}
+
+	if c.RefreshBindingIPv6Prefix < 0 || c.RefreshBindingIPv6Prefix > 128 {
+		return &ValidationError{Field: "Security.RefreshBindingIPv6Prefix", Message: "must be between 0 and 128"}
+	}
+
+	for clientID, secret := range c.IntrospectionClients {
+		if len(secret) < 32 {
+			return &ValidationError{Field: "Security.IntrospectionClients", Message: "secret of " + clientID + " must be at least 32 characters"}
+		}
+	}
+
+	if c.PasswordHistoryDepth < 0 {
+		return &ValidationError{Field: "Security.PasswordHistoryDepth", Message: "must not be negative"}
+	}
+
+	if c.BreachedPasswordDir != "" && c.BreachedPasswordMinCount < 1 {
+		return &ValidationError{Field: "Security.BreachedPasswordMinCount", Message: "must be at least 1"}
+	}
+
 	return nil
 }
 
@@ -206,6 +519,12 @@ func (c *RateLimitingConfig) Validate() error {
 	return nil
 }
 
+// I18nConfig holds localization configuration
+type I18nConfig struct {
+	// CatalogDir holds <locale>.json files overriding the embedded messages
+	CatalogDir string
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	IsDevelopment bool
@@ -291,12 +610,10 @@ type JWTConfig struct {
 	Issuer        string        `mapstructure:"issuer"`
 }
 
-// Validate checks if JWT configuration is valid
+// Validate checks if JWT configuration is valid. The secret is only needed
+// by HS256 and is checked with the other signing key settings in
+// SecurityConfig.
 func (c *JWTConfig) Validate() error {
-	if c.Secret == "" {
-		return &ValidationError{Field: "JWT.Secret", Message: "cannot be empty"}
-	}
-
 	return nil
 }
 
@@ -333,18 +650,51 @@ func LoadConfig() (*Config, error) {
 	v.SetDefault("EMAIL_FROM_NAME", "Qubool Kallyaanam")
 	v.SetDefault("OTP_EXPIRY_MINS", 15)
 	v.SetDefault("APP_ENV", "development")
+	v.SetDefault("SMTP_PORT", 587)
+	v.SetDefault("SMTP_SECURITY", "starttls")
+	v.SetDefault("SMTP_TIMEOUT_SECONDS", 10)
+	v.SetDefault("SMTP_INSECURE_SKIP_VERIFY", false)
+	v.SetDefault("EMAIL_OUTBOX_POLL_INTERVAL_SECONDS", 5)
+	v.SetDefault("EMAIL_OUTBOX_BATCH_SIZE", 20)
+	v.SetDefault("EMAIL_OUTBOX_MAX_ATTEMPTS", 8)
+	v.SetDefault("EMAIL_OUTBOX_BASE_BACKOFF_SECONDS", 30)
+	v.SetDefault("EMAIL_OUTBOX_MAX_BACKOFF_SECONDS", 3600)
+	v.SetDefault("DEV_MAILBOX_ENABLED", v.GetString("APP_ENV") == "development")
+	v.SetDefault("DEV_MAILBOX_MAX_MESSAGES", 200)
+
+	// SMS config
+	v.SetDefault("SMS_PROVIDER", "console")
+	v.SetDefault("SMS_TIMEOUT_SECONDS", 10)
 
 	// OTP config
 	v.SetDefault("OTP_LENGTH", 6)
+	v.SetDefault("OTP_MAX_ATTEMPTS", 5)
+	v.SetDefault("OTP_RESEND_COOLDOWN_SECONDS", 60)
+	v.SetDefault("OTP_RESEND_DAILY_LIMIT", 5)
+	// Login codes are short-lived, other purposes use the global OTP settings
+	v.SetDefault("OTP_LOGIN_EXPIRY_MINS", 5)
+	v.SetDefault("OTP_LOGIN_MAX_ATTEMPTS", 3)
 
 	// Security config
+	v.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	v.SetDefault("ARGON2_MEMORY_KIB", 65536)
+	v.SetDefault("ARGON2_ITERATIONS", 3)
+	v.SetDefault("ARGON2_PARALLELISM", 2)
 	v.SetDefault("BCRYPT_COST", 12)
 	v.SetDefault("MIN_PASSWORD_CHARS", 8)
+	v.SetDefault("MIN_PASSWORD_SCORE", 3)
+	v.SetDefault("PASSWORD_HISTORY_DEPTH", 5)
+	v.SetDefault("BREACHED_PASSWORD_MIN_COUNT", 1)
+	v.SetDefault("JWT_SIGNING_ALGORITHM", "HS256")
+	v.SetDefault("JWT_KEY_ID", "auth-key-1")
 	v.SetDefault("ACCESS_TOKEN_EXPIRY_MINUTES", 15)
 	v.SetDefault("REFRESH_TOKEN_EXPIRY_HOURS", 24)
-	v.SetDefault("TOKEN_ISSUER", "qubool-kallyaanam-auth")
 	v.SetDefault("LOGIN_ATTEMPTS_THRESHOLD", 5)
 	v.SetDefault("LOGIN_THROTTLE_DURATION_MINUTES", 15)
+	v.SetDefault("SESSION_LIMIT_POLICY", "evict")
+	v.SetDefault("REFRESH_BINDING_POLICY", "log")
+	v.SetDefault("REFRESH_BINDING_IPV4_PREFIX", 24)
+	v.SetDefault("REFRESH_BINDING_IPV6_PREFIX", 64)
 
 	// Rate limiting config
 	v.SetDefault("RATE_LIMIT_MAX_REQUESTS", 5)
@@ -380,6 +730,17 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// Development reaches the service directly on its port. The public URL
+	// is also the token issuer unless TOKEN_ISSUER says otherwise.
+	publicBaseURL := strings.TrimSuffix(v.GetString("PUBLIC_BASE_URL"), "/")
+	if publicBaseURL == "" && v.GetString("APP_ENV") == "development" {
+		publicBaseURL = "http://localhost:" + v.GetString("PORT")
+	}
+	tokenIssuer := v.GetString("TOKEN_ISSUER")
+	if tokenIssuer == "" {
+		tokenIssuer = publicBaseURL
+	}
+
 	// Parse token expiry durations
 	tokenExpiry, err := time.ParseDuration(v.GetString("JWT_TOKEN_EXPIRY"))
 	if err != nil {
@@ -391,11 +752,42 @@ func LoadConfig() (*Config, error) {
 		refreshExpiry = 24 * time.Hour
 	}
 
+	// OTP digests need a key of their own. Development may go without one,
+	// a sub-key is then derived from the JWT secret so the two never match.
+	otpSecret := v.GetString("OTP_HMAC_SECRET")
+	if otpSecret == "" && v.GetString("APP_ENV") == "development" && v.GetString("JWT_SECRET") != "" {
+		otpSecret, err = deriveSubKey(v.GetString("JWT_SECRET"), "otp")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Same for the key queued emails are encrypted with
+	outboxPayloadKey := v.GetString("EMAIL_OUTBOX_PAYLOAD_KEY")
+	if outboxPayloadKey == "" && v.GetString("APP_ENV") == "development" && v.GetString("JWT_SECRET") != "" {
+		outboxPayloadKey, err = deriveSubKey(v.GetString("JWT_SECRET"), "email-outbox")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	introspectionClients, err := parseIntrospectionClients(v.GetString("INTROSPECTION_CLIENTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	sessionLimits, err := parseSessionLimits(v.GetString("SESSION_LIMITS"))
+	if err != nil {
+		return nil, err
+	}
+
 	// Create config with defaults and environment variable overrides
 	config := &Config{
 		Server: ServerConfig{
 			Port:              v.GetString("PORT"),
 			RequestTimeoutSec: v.GetInt("REQUEST_TIMEOUT_SECONDS"),
+			PublicBaseURL:     publicBaseURL,
+			IsDevelopment:     v.GetString("APP_ENV") == "development",
 		},
 		Database: DatabaseConfig{
 			DSN: v.GetString("DATABASE_URL"),
@@ -405,20 +797,75 @@ func LoadConfig() (*Config, error) {
 			FromName:      v.GetString("EMAIL_FROM_NAME"),
 			OTPExpiryMins: v.GetInt("OTP_EXPIRY_MINS"),
 			IsDevelopment: v.GetString("APP_ENV") == "development",
+
+			SMTPHost:               v.GetString("SMTP_HOST"),
+			SMTPPort:               v.GetInt("SMTP_PORT"),
+			SMTPUsername:           v.GetString("SMTP_USERNAME"),
+			SMTPPassword:           v.GetString("SMTP_PASSWORD"),
+			SMTPSecurity:           strings.ToLower(v.GetString("SMTP_SECURITY")),
+			SMTPTimeoutSec:         v.GetInt("SMTP_TIMEOUT_SECONDS"),
+			SMTPInsecureSkipVerify: v.GetBool("SMTP_INSECURE_SKIP_VERIFY"),
+			TemplateDir:            v.GetString("EMAIL_TEMPLATE_DIR"),
+
+			OutboxPollIntervalSec: v.GetInt("EMAIL_OUTBOX_POLL_INTERVAL_SECONDS"),
+			OutboxBatchSize:       v.GetInt("EMAIL_OUTBOX_BATCH_SIZE"),
+			OutboxMaxAttempts:     v.GetInt("EMAIL_OUTBOX_MAX_ATTEMPTS"),
+			OutboxBaseBackoffSec:  v.GetInt("EMAIL_OUTBOX_BASE_BACKOFF_SECONDS"),
+			OutboxMaxBackoffSec:   v.GetInt("EMAIL_OUTBOX_MAX_BACKOFF_SECONDS"),
+			OutboxPayloadKey:      outboxPayloadKey,
+
+			DevMailboxEnabled:     v.GetBool("DEV_MAILBOX_ENABLED"),
+			DevMailboxDir:         v.GetString("DEV_MAILBOX_DIR"),
+			DevMailboxMaxMessages: v.GetInt("DEV_MAILBOX_MAX_MESSAGES"),
+		},
+		SMS: SMSConfig{
+			Provider:      strings.ToLower(v.GetString("SMS_PROVIDER")),
+			IsDevelopment: v.GetString("APP_ENV") == "development",
+			FilePath:      v.GetString("SMS_FILE_PATH"),
+			GatewayURL:    v.GetString("SMS_GATEWAY_URL"),
+			GatewayAPIKey: v.GetString("SMS_GATEWAY_API_KEY"),
+			SenderID:      v.GetString("SMS_SENDER_ID"),
+			TimeoutSec:    v.GetInt("SMS_TIMEOUT_SECONDS"),
 		},
 		OTP: OTPConfig{
-			Length:     v.GetInt("OTP_LENGTH"),
-			ExpiryMins: v.GetInt("OTP_EXPIRY_MINS"),
+			Length:            v.GetInt("OTP_LENGTH"),
+			ExpiryMins:        v.GetInt("OTP_EXPIRY_MINS"),
+			HMACSecret:        otpSecret,
+			IsDevelopment:     v.GetString("APP_ENV") == "development",
+			MaxAttempts:       v.GetInt("OTP_MAX_ATTEMPTS"),
+			ResendCooldownSec: v.GetInt("OTP_RESEND_COOLDOWN_SECONDS"),
+			ResendDailyLimit:  v.GetInt("OTP_RESEND_DAILY_LIMIT"),
+			Purposes:          loadOTPPolicies(v),
 		},
 		Security: SecurityConfig{
+			PasswordHashAlgorithm:        strings.ToLower(v.GetString("PASSWORD_HASH_ALGORITHM")),
+			Argon2MemoryKiB:              v.GetInt("ARGON2_MEMORY_KIB"),
+			Argon2Iterations:             v.GetInt("ARGON2_ITERATIONS"),
+			Argon2Parallelism:            v.GetInt("ARGON2_PARALLELISM"),
 			BcryptCost:                   v.GetInt("BCRYPT_COST"),
 			MinPasswordChars:             v.GetInt("MIN_PASSWORD_CHARS"),
+			MinPasswordScore:             v.GetInt("MIN_PASSWORD_SCORE"),
+			PasswordHistoryDepth:         v.GetInt("PASSWORD_HISTORY_DEPTH"),
+			BreachedPasswordDir:          v.GetString("BREACHED_PASSWORD_DIR"),
+			BreachedPasswordMinCount:     v.GetInt("BREACHED_PASSWORD_MIN_COUNT"),
 			JWTSecret:                    v.GetString("JWT_SECRET"),
+			JWTSigningAlgorithm:          v.GetString("JWT_SIGNING_ALGORITHM"),
+			JWTPrivateKeyFile:            v.GetString("JWT_PRIVATE_KEY_FILE"),
+			JWTPublicKeyFile:             v.GetString("JWT_PUBLIC_KEY_FILE"),
+			JWTKeyID:                     v.GetString("JWT_KEY_ID"),
+			JWTKeyManifest:               v.GetString("JWT_KEY_MANIFEST"),
 			AccessTokenExpiryMinutes:     v.GetInt("ACCESS_TOKEN_EXPIRY_MINUTES"),
 			RefreshTokenExpiryHours:      v.GetInt("REFRESH_TOKEN_EXPIRY_HOURS"),
-			TokenIssuer:                  v.GetString("TOKEN_ISSUER"),
+			TokenIssuer:                  tokenIssuer,
+			IntrospectionClients:         introspectionClients,
+			SessionLimits:                sessionLimits,
+			SessionLimitPolicy:           strings.ToLower(v.GetString("SESSION_LIMIT_POLICY")),
+			RefreshBindingPolicy:         strings.ToLower(v.GetString("REFRESH_BINDING_POLICY")),
+			RefreshBindingIPv4Prefix:     v.GetInt("REFRESH_BINDING_IPV4_PREFIX"),
+			RefreshBindingIPv6Prefix:     v.GetInt("REFRESH_BINDING_IPV6_PREFIX"),
 			LoginAttemptsThreshold:       v.GetInt("LOGIN_ATTEMPTS_THRESHOLD"),
 			LoginThrottleDurationMinutes: v.GetInt("LOGIN_THROTTLE_DURATION_MINUTES"),
+			IsDevelopment:                v.GetString("APP_ENV") == "development",
 		},
 		RateLimiting: RateLimitingConfig{
 			MaxRequestsPerMinute: v.GetInt("RATE_LIMIT_MAX_REQUESTS"),
@@ -429,6 +876,9 @@ func LoadConfig() (*Config, error) {
 			LogLevel:      v.GetString("LOG_LEVEL"),
 		},
 		Redis: redisConfig,
+		I18n: I18nConfig{
+			CatalogDir: v.GetString("MESSAGE_CATALOG_DIR"),
+		},
 		JWT: JWTConfig{
 			Secret:        v.GetString("JWT_SECRET"),
 			TokenExpiry:   tokenExpiry,
@@ -445,6 +895,88 @@ func LoadConfig() (*Config, error) {
 	return config, nil
 }
 
+// loadOTPPolicies reads OTP_<PURPOSE>_LENGTH, OTP_<PURPOSE>_EXPIRY_MINS and
+// OTP_<PURPOSE>_MAX_ATTEMPTS for every purpose, falling back to OTP_LENGTH,
+// OTP_EXPIRY_MINS and OTP_MAX_ATTEMPTS
+func loadOTPPolicies(v *viper.Viper) map[string]OTPPolicyConfig {
+	policies := make(map[string]OTPPolicyConfig, len(OTPPurposes))
+	for _, purpose := range OTPPurposes {
+		prefix := "OTP_" + strings.ToUpper(purpose) + "_"
+
+		policy := OTPPolicyConfig{
+			Length:      v.GetInt("OTP_LENGTH"),
+			ExpiryMins:  v.GetInt("OTP_EXPIRY_MINS"),
+			MaxAttempts: v.GetInt("OTP_MAX_ATTEMPTS"),
+		}
+		if v.IsSet(prefix + "LENGTH") {
+			policy.Length = v.GetInt(prefix + "LENGTH")
+		}
+		if v.IsSet(prefix + "EXPIRY_MINS") {
+			policy.ExpiryMins = v.GetInt(prefix + "EXPIRY_MINS")
+		}
+		if v.IsSet(prefix + "MAX_ATTEMPTS") {
+			policy.MaxAttempts = v.GetInt(prefix + "MAX_ATTEMPTS")
+		}
+
+		policies[purpose] = policy
+	}
+	return policies
+}
+
+// deriveSubKey derives a hex-encoded 256-bit key for one purpose (label)
+// from a secret with HKDF-SHA256
+func deriveSubKey(secret, label string) (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(label)), key); err != nil {
+		return "", fmt.Errorf("failed to derive %s key: %w", label, err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// parseIntrospectionClients reads INTROSPECTION_CLIENTS, a comma-separated
+// list of client_id:secret pairs
+func parseIntrospectionClients(value string) (map[string]string, error) {
+	clients := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		clientID, secret, ok := strings.Cut(pair, ":")
+		if !ok || clientID == "" || secret == "" {
+			return nil, &ValidationError{Field: "Security.IntrospectionClients", Message: "must be a comma-separated list of client_id:secret pairs"}
+		}
+		if _, exists := clients[clientID]; exists {
+			return nil, &ValidationError{Field: "Security.IntrospectionClients", Message: "duplicate client " + clientID}
+		}
+		clients[clientID] = secret
+	}
+	return clients, nil
+}
+
+// parseSessionLimits reads SESSION_LIMITS, a comma-separated list of
+// role:limit pairs such as "user:5,premium:2,*:10"
+func parseSessionLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		role, rawLimit, ok := strings.Cut(pair, ":")
+		role = strings.TrimSpace(role)
+		limit, err := strconv.Atoi(strings.TrimSpace(rawLimit))
+		if !ok || role == "" || err != nil {
+			return nil, &ValidationError{Field: "Security.SessionLimits", Message: "must be a comma-separated list of role:limit pairs"}
+		}
+		if _, exists := limits[role]; exists {
+			return nil, &ValidationError{Field: "Security.SessionLimits", Message: "duplicate role " + role}
+		}
+		limits[role] = limit
+	}
+	return limits, nil
+}
+
 // Helper functions below are kept for backward compatibility
 // but will be deprecated in favor of Viper
 
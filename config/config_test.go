@@ -0,0 +1,221 @@
+// config/config_test.go
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOTPConfigValidateHMACSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		isDevelopment bool
+		wantErr       string
+	}{
+		{name: "secret set", secret: "otp-secret"},
+		{name: "secret set in development", secret: "otp-secret", isDevelopment: true},
+		{name: "missing outside development", wantErr: "cannot be empty outside development"},
+		{name: "missing in development without JWT_SECRET", isDevelopment: true, wantErr: "cannot be empty without JWT_SECRET"},
+	}
+
+	purposes := make(map[string]OTPPolicyConfig)
+	for _, purpose := range OTPPurposes {
+		purposes[purpose] = OTPPolicyConfig{Length: 6, ExpiryMins: 15, MaxAttempts: 5}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &OTPConfig{
+				Length:            6,
+				ExpiryMins:        15,
+				HMACSecret:        tt.secret,
+				IsDevelopment:     tt.isDevelopment,
+				MaxAttempts:       5,
+				ResendCooldownSec: 60,
+				ResendDailyLimit:  5,
+				Purposes:          purposes,
+			}
+
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != "OTP.HMACSecret" || validationErr.Message != tt.wantErr {
+				t.Errorf("Validate() error = %v, want OTP.HMACSecret %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeriveSubKey(t *testing.T) {
+	otpKey, err := deriveSubKey("jwt-secret", "otp")
+	if err != nil {
+		t.Fatalf("deriveSubKey() error = %v", err)
+	}
+
+	again, _ := deriveSubKey("jwt-secret", "otp")
+	otherLabel, _ := deriveSubKey("jwt-secret", "other")
+	otherSecret, _ := deriveSubKey("another-secret", "otp")
+
+	if len(otpKey) != 64 {
+		t.Errorf("derived key %q is not 256 bits of hex", otpKey)
+	}
+	if otpKey == "jwt-secret" {
+		t.Error("derived key equals the secret it was derived from")
+	}
+	if again != otpKey {
+		t.Errorf("derived key is not stable: %q != %q", again, otpKey)
+	}
+	if otherLabel == otpKey || otherSecret == otpKey {
+		t.Error("derived key doesn't depend on both the secret and the label")
+	}
+}
+
+// assertValidationError checks that err is a ValidationError of field with
+// message, or nil when field is empty
+func assertValidationError(t *testing.T, err error, field, message string) {
+	t.Helper()
+
+	if field == "" {
+		if err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+		return
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != field || validationErr.Message != message {
+		t.Errorf("Validate() error = %v, want %s %q", err, field, message)
+	}
+}
+
+func TestEmailConfigValidateDelivery(t *testing.T) {
+	const payloadKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+	tests := []struct {
+		name          string
+		isDevelopment bool
+		smtpHost      string
+		devMailbox    bool
+		payloadKey    string
+		wantField     string
+		wantErr       string
+	}{
+		{name: "SMTP", smtpHost: "smtp.example.com", payloadKey: payloadKey},
+		{name: "development mailbox", isDevelopment: true, devMailbox: true, payloadKey: payloadKey},
+		{name: "SMTP and mailbox in development", isDevelopment: true, smtpHost: "smtp.example.com", devMailbox: true, payloadKey: payloadKey},
+		{
+			name:       "no SMTP outside development",
+			payloadKey: payloadKey,
+			wantField:  "Email.SMTPHost",
+			wantErr:    "cannot be empty outside development",
+		},
+		{
+			name:          "no SMTP with the mailbox disabled",
+			isDevelopment: true,
+			payloadKey:    payloadKey,
+			wantField:     "Email.SMTPHost",
+			wantErr:       "cannot be empty with the development mailbox disabled",
+		},
+		{
+			name:       "mailbox outside development",
+			smtpHost:   "smtp.example.com",
+			devMailbox: true,
+			payloadKey: payloadKey,
+			wantField:  "Email.DevMailboxEnabled",
+			wantErr:    "can only be enabled in development",
+		},
+		{
+			name:      "no payload key outside development",
+			smtpHost:  "smtp.example.com",
+			wantField: "Email.OutboxPayloadKey",
+			wantErr:   "cannot be empty outside development",
+		},
+		{
+			name:          "no payload key in development without JWT_SECRET",
+			isDevelopment: true,
+			devMailbox:    true,
+			wantField:     "Email.OutboxPayloadKey",
+			wantErr:       "cannot be empty without JWT_SECRET",
+		},
+		{
+			name:       "short payload key",
+			smtpHost:   "smtp.example.com",
+			payloadKey: payloadKey[:32],
+			wantField:  "Email.OutboxPayloadKey",
+			wantErr:    "must be 64 hex characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &EmailConfig{
+				FromEmail:             "noreply@example.com",
+				FromName:              "Qubool Kallyaanam",
+				OTPExpiryMins:         15,
+				IsDevelopment:         tt.isDevelopment,
+				SMTPHost:              tt.smtpHost,
+				SMTPPort:              587,
+				SMTPSecurity:          "starttls",
+				SMTPTimeoutSec:        10,
+				OutboxPollIntervalSec: 5,
+				OutboxBatchSize:       20,
+				OutboxMaxAttempts:     8,
+				OutboxBaseBackoffSec:  30,
+				OutboxMaxBackoffSec:   3600,
+				OutboxPayloadKey:      tt.payloadKey,
+				DevMailboxEnabled:     tt.devMailbox,
+			}
+			assertValidationError(t, c.Validate(), tt.wantField, tt.wantErr)
+		})
+	}
+}
+
+func TestSecurityConfigValidateTokenIssuer(t *testing.T) {
+	const wantErr = "must be an https URL without query or fragment"
+
+	tests := []struct {
+		name          string
+		issuer        string
+		isDevelopment bool
+		wantErr       bool
+	}{
+		{name: "https", issuer: "https://auth.example.com"},
+		{name: "https with a path", issuer: "https://example.com/auth"},
+		{name: "http in development", issuer: "http://localhost:8080", isDevelopment: true},
+		{name: "http outside development", issuer: "http://auth.example.com", wantErr: true},
+		{name: "bare name", issuer: "auth-service", wantErr: true},
+		{name: "empty", issuer: "", wantErr: true},
+		{name: "query", issuer: "https://auth.example.com?tenant=1", wantErr: true},
+		{name: "fragment", issuer: "https://auth.example.com#top", wantErr: true},
+		{name: "other scheme", issuer: "ftp://auth.example.com", isDevelopment: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &SecurityConfig{
+				PasswordHashAlgorithm: "bcrypt",
+				BcryptCost:            12,
+				MinPasswordChars:      12,
+				MinPasswordScore:      3,
+				JWTSecret:             "jwt-secret",
+				JWTSigningAlgorithm:   "HS256",
+				JWTKeyID:              "default",
+				TokenIssuer:           tt.issuer,
+				SessionLimitPolicy:    "evict",
+				RefreshBindingPolicy:  "log",
+				IsDevelopment:         tt.isDevelopment,
+			}
+
+			field, message := "", ""
+			if tt.wantErr {
+				field, message = "Security.TokenIssuer", wantErr
+			}
+			assertValidationError(t, c.Validate(), field, message)
+		})
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/config"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/handler"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/middleware"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
 	postgreRepo "github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/postgres"
 	redisRepo "github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/redis"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/database"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/redis"
@@ -21,22 +23,31 @@ import (
 
 // Container holds all application dependencies
 type Container struct {
-	Config     *config.Config
-	Router     *gin.Engine
-	AuthRoutes *gin.RouterGroup
-	Logger     *logger.Logger
+	Config          *config.Config
+	Router          *gin.Engine
+	AuthRoutes      *gin.RouterGroup
+	ProtectedRoutes *gin.RouterGroup // Auth routes that require an access token
+	ServiceRoutes   *gin.RouterGroup // Auth routes for other services, using client credentials
+	Logger          *logger.Logger
 
 	// Services
 	AuthService     service.AuthService
 	OTPService      service.OTPService
 	EmailService    service.EmailService
+	SMSService      service.SMSService
 	SecurityService service.SecurityService
 	MetricsService  service.MetricsService
 	RedisService    service.RedisService
 
+	// Background workers
+	EmailDispatcher *service.EmailDispatcher
+
 	// Handlers
-	AuthHandler   *handler.AuthHandler
-	HealthHandler *handler.HealthHandler
+	AuthHandler       *handler.AuthHandler
+	HealthHandler     *handler.HealthHandler
+	JWKSHandler       *handler.JWKSHandler
+	DiscoveryHandler  *handler.DiscoveryHandler
+	DevMailboxHandler *handler.DevMailboxHandler // Only set in development unless DEV_MAILBOX_ENABLED=false
 }
 
 // Initialize creates a new dependency injection container with all dependencies wired up
@@ -53,6 +64,11 @@ func Initialize() (*Container, error) {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
+	// Load message catalog overrides, the embedded catalogs are always available
+	if err := i18n.LoadOverrides(cfg.I18n.CatalogDir); err != nil {
+		return nil, fmt.Errorf("failed to load message catalogs: %w", err)
+	}
+
 	// Initialize database connection
 	db, err := database.NewPostgresConnection(cfg.Database.DSN)
 	if err != nil {
@@ -71,9 +87,13 @@ func Initialize() (*Container, error) {
 	var userRepo repository.UserRepository
 	userRepo = postgreRepo.NewUserRepository(db)
 
+	// Initialize email outbox repository
+	var emailOutboxRepo repository.EmailOutboxRepository
+	emailOutboxRepo = postgreRepo.NewEmailOutboxRepository(db)
+
 	// Initialize OTP repository with Redis
 	var otpRepo repository.OTPRepository
-	otpRepo = redisRepo.NewOTPRepository(redisClient)
+	otpRepo = redisRepo.NewOTPRepository(redisClient, cfg.OTP.HMACSecret)
 
 	// Initialize Redis service
 	var redisService service.RedisService
@@ -82,38 +102,165 @@ func Initialize() (*Container, error) {
 		Password:     cfg.Redis.Password,
 		DB:           cfg.Redis.DB,
 		TokenExpiry:  time.Duration(cfg.Security.RefreshTokenExpiryHours) * time.Hour,
+		AccessExpiry: time.Duration(cfg.Security.AccessTokenExpiryMinutes) * time.Minute,
 		ThrottleRate: cfg.Security.LoginAttemptsThreshold,
 		ThrottleTTL:  time.Duration(cfg.Security.LoginThrottleDurationMinutes) * time.Minute,
 	}, appLogger)
 
 	// Initialize services
 	var otpService service.OTPService
+	otpPolicies := make(map[model.OTPPurpose]service.OTPPolicy, len(cfg.OTP.Purposes))
+	for purpose, policy := range cfg.OTP.Purposes {
+		otpPolicies[model.OTPPurpose(purpose)] = service.OTPPolicy{
+			Length:      policy.Length,
+			Expiry:      time.Duration(policy.ExpiryMins) * time.Minute,
+			MaxAttempts: policy.MaxAttempts,
+		}
+	}
 	otpService = service.NewOTPService(service.OTPConfig{
-		Length:     cfg.OTP.Length,
-		ExpiryMins: cfg.OTP.ExpiryMins,
+		Length:            cfg.OTP.Length,
+		Policies:          otpPolicies,
+		ResendCooldownSec: cfg.OTP.ResendCooldownSec,
+		ResendDailyLimit:  cfg.OTP.ResendDailyLimit,
 	}, otpRepo)
 
+	// Use SMTP delivery when a server is configured (required outside
+	// development and when the development mailbox is disabled)
+	var emailSender service.EmailSender
+	if cfg.Email.SMTPHost != "" {
+		emailSender, err = service.NewSMTPSender(service.SMTPConfig{
+			Host:               cfg.Email.SMTPHost,
+			Port:               cfg.Email.SMTPPort,
+			Username:           cfg.Email.SMTPUsername,
+			Password:           cfg.Email.SMTPPassword,
+			Security:           cfg.Email.SMTPSecurity,
+			Timeout:            time.Duration(cfg.Email.SMTPTimeoutSec) * time.Second,
+			InsecureSkipVerify: cfg.Email.SMTPInsecureSkipVerify,
+		})
+		if err != nil {
+			appLogger.Fatal("Failed to initialize SMTP sender", appLogger.Field("error", err.Error()))
+			return nil, fmt.Errorf("failed to initialize SMTP sender: %w", err)
+		}
+	}
+
+	// In development capture every mail so it can be read back over HTTP,
+	// still forwarding to the SMTP server if one is configured
+	var devMailbox *service.DevMailbox
+	if cfg.Email.DevMailboxEnabled {
+		devMailbox, err = service.NewDevMailbox(service.DevMailboxConfig{
+			MaxMessages: cfg.Email.DevMailboxMaxMessages,
+			Dir:         cfg.Email.DevMailboxDir,
+		}, emailSender)
+		if err != nil {
+			appLogger.Fatal("Failed to initialize development mailbox", appLogger.Field("error", err.Error()))
+			return nil, fmt.Errorf("failed to initialize development mailbox: %w", err)
+		}
+		emailSender = devMailbox
+	}
+
+	// Load email templates (embedded unless a directory override is configured)
+	emailTemplates, err := service.NewEmailTemplateRegistry(cfg.Email.TemplateDir)
+	if err != nil {
+		appLogger.Fatal("Failed to load email templates", appLogger.Field("error", err.Error()))
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
+	}
+
+	// Queued emails may hold one-time codes, encrypt them at rest
+	outboxCipher, err := service.NewOutboxPayloadCipher(cfg.Email.OutboxPayloadKey)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize outbox payload cipher", appLogger.Field("error", err.Error()))
+		return nil, fmt.Errorf("failed to initialize outbox payload cipher: %w", err)
+	}
+
 	var emailService service.EmailService
 	emailService, err = service.NewEmailService(service.EmailConfig{
 		FromEmail:     cfg.Email.FromEmail,
 		FromName:      cfg.Email.FromName,
-		OTPExpiryMins: cfg.Email.OTPExpiryMins,
+		OTPExpiryMins: cfg.OTP.Purposes["registration"].ExpiryMins,
 		IsDevelopment: cfg.Email.IsDevelopment,
-	})
+		PayloadCipher: outboxCipher,
+	}, emailSender, emailTemplates, emailOutboxRepo)
 	if err != nil {
 		appLogger.Fatal("Failed to initialize email service", appLogger.Field("error", err.Error()))
 		return nil, fmt.Errorf("failed to initialize email service: %w", err)
 	}
 
+	// SMS provider, the console stand-in is only allowed in development
+	var smsService service.SMSService
+	switch cfg.SMS.Provider {
+	case service.SMSProviderHTTP:
+		smsService, err = service.NewHTTPSMSService(service.HTTPSMSConfig{
+			URL:      cfg.SMS.GatewayURL,
+			APIKey:   cfg.SMS.GatewayAPIKey,
+			SenderID: cfg.SMS.SenderID,
+			Timeout:  time.Duration(cfg.SMS.TimeoutSec) * time.Second,
+		})
+	default:
+		smsService, err = service.NewConsoleSMSService(cfg.SMS.FilePath, appLogger)
+	}
+	if err != nil {
+		appLogger.Fatal("Failed to initialize SMS service", appLogger.Field("error", err.Error()))
+		return nil, fmt.Errorf("failed to initialize SMS service: %w", err)
+	}
+
+	// Breached-password check, only when a corpus is configured
+	var breachChecker service.BreachedPasswordChecker
+	if cfg.Security.BreachedPasswordDir != "" {
+		rangeSource, ranges, err := service.NewFilePasswordRangeSource(cfg.Security.BreachedPasswordDir)
+		if err != nil {
+			appLogger.Fatal("Failed to load breached password corpus", appLogger.Field("error", err.Error()))
+			return nil, fmt.Errorf("failed to load breached password corpus: %w", err)
+		}
+		appLogger.Info("Breached password corpus loaded",
+			appLogger.Field("dir", cfg.Security.BreachedPasswordDir),
+			appLogger.Field("ranges", ranges))
+		breachChecker = service.NewBreachedPasswordChecker(rangeSource, cfg.Security.BreachedPasswordMinCount, appLogger)
+	}
+
+	// Token signing keys, other services only need the public half of an asymmetric key.
+	// With a key manifest, retired keys keep verifying for as long as a refresh token lives.
+	refreshExpiry := time.Duration(cfg.Security.RefreshTokenExpiryHours) * time.Hour
+	var keyRing *service.JWTKeyRing
+	if cfg.Security.JWTKeyManifest != "" {
+		keyRing, err = service.LoadJWTKeyRing(cfg.Security.JWTKeyManifest, refreshExpiry)
+	} else {
+		var signingKey *service.JWTSigningKey
+		if cfg.Security.JWTSigningAlgorithm == service.JWTAlgorithmHS256 {
+			signingKey, err = service.NewHMACSigningKey(cfg.Security.JWTKeyID, cfg.Security.JWTSecret)
+		} else {
+			signingKey, err = service.LoadJWTSigningKey(cfg.Security.JWTSigningAlgorithm, cfg.Security.JWTKeyID,
+				cfg.Security.JWTPrivateKeyFile, cfg.Security.JWTPublicKeyFile)
+		}
+		if err == nil {
+			keyRing, err = service.NewJWTKeyRing(signingKey)
+		}
+	}
+	if err != nil {
+		appLogger.Fatal("Failed to load JWT signing keys", appLogger.Field("error", err.Error()))
+		return nil, fmt.Errorf("failed to load JWT signing keys: %w", err)
+	}
+	appLogger.Info("JWT signing keys loaded",
+		appLogger.Field("signing_kid", keyRing.SigningKey().KeyID),
+		appLogger.Field("algorithm", keyRing.SigningKey().Method.Alg()))
+
 	var securityService service.SecurityService
 	securityService, err = service.NewSecurityService(service.SecurityConfig{
-		BcryptCost:       cfg.Security.BcryptCost,
+		PasswordHasher: service.PasswordHasherConfig{
+			Algorithm:  cfg.Security.PasswordHashAlgorithm,
+			BcryptCost: cfg.Security.BcryptCost,
+			Argon2: service.Argon2Params{
+				Memory:      uint32(cfg.Security.Argon2MemoryKiB),
+				Iterations:  uint32(cfg.Security.Argon2Iterations),
+				Parallelism: uint8(cfg.Security.Argon2Parallelism),
+			},
+		},
 		MinPasswordChars: cfg.Security.MinPasswordChars,
-		JWTSecret:        cfg.Security.JWTSecret,
+		MinPasswordScore: cfg.Security.MinPasswordScore,
+		KeyRing:          keyRing,
 		TokenExpiry:      time.Duration(cfg.Security.AccessTokenExpiryMinutes) * time.Minute,
-		RefreshExpiry:    time.Duration(cfg.Security.RefreshTokenExpiryHours) * time.Hour,
+		RefreshExpiry:    refreshExpiry,
 		Issuer:           cfg.Security.TokenIssuer,
-	}, redisService)
+	}, redisService, breachChecker)
 	if err != nil {
 		appLogger.Fatal("Failed to initialize security service", appLogger.Field("error", err.Error()))
 		return nil, fmt.Errorf("failed to initialize security service: %w", err)
@@ -123,22 +270,62 @@ func Initialize() (*Container, error) {
 	var metricsService service.MetricsService
 	metricsService = service.NewNoOpMetricsService()
 
+	// Email outbox dispatcher, started by main
+	emailDispatcher := service.NewEmailDispatcher(service.EmailDispatcherConfig{
+		PollInterval: time.Duration(cfg.Email.OutboxPollIntervalSec) * time.Second,
+		BatchSize:    cfg.Email.OutboxBatchSize,
+		MaxAttempts:  cfg.Email.OutboxMaxAttempts,
+		BaseBackoff:  time.Duration(cfg.Email.OutboxBaseBackoffSec) * time.Second,
+		MaxBackoff:   time.Duration(cfg.Email.OutboxMaxBackoffSec) * time.Second,
+		SendTimeout:  time.Duration(cfg.Email.SMTPTimeoutSec) * time.Second,
+
+		PayloadCipher: outboxCipher,
+	}, emailOutboxRepo, emailService, metricsService, appLogger)
+
 	// Initialize auth service
 	var authService service.AuthService
+	passwordHistoryService := service.NewPasswordHistoryService(
+		postgreRepo.NewPasswordHistoryRepository(db),
+		securityService,
+		cfg.Security.PasswordHistoryDepth,
+	)
+
+	// Concurrent session limits, checked on every login
+	var sessionLimiter service.SessionLimiter
+	sessionLimiter, err = service.NewSessionLimiter(service.SessionLimitConfig{
+		Limits: cfg.Security.SessionLimits,
+		Policy: cfg.Security.SessionLimitPolicy,
+	}, redisService, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize session limiter", appLogger.Field("error", err.Error()))
+		return nil, fmt.Errorf("failed to initialize session limiter: %w", err)
+	}
+
 	authService = service.NewAuthService(
 		userRepo,
+		passwordHistoryService,
 		otpService,
 		emailService,
+		smsService,
 		securityService,
 		metricsService,
 		appLogger,
 		redisService,
+		sessionLimiter,
+		service.RefreshBindingConfig{
+			Policy:     cfg.Security.RefreshBindingPolicy,
+			IPv4Prefix: cfg.Security.RefreshBindingIPv4Prefix,
+			IPv6Prefix: cfg.Security.RefreshBindingIPv6Prefix,
+		},
 	)
 
 	// Initialize Gin router
 	router := gin.New()
 	router.Use(gin.Recovery())
 
+	// Negotiate the response language for every request
+	router.Use(middleware.LocaleMiddleware())
+
 	// Add timeout middleware
 	router.Use(middleware.TimeoutMiddleware(
 		time.Duration(cfg.Server.RequestTimeoutSec)*time.Second,
@@ -155,6 +342,15 @@ func Initialize() (*Container, error) {
 		appLogger,
 	))
 
+	// Routes below need a valid access token
+	protectedRoutes := authRoutes.Group("")
+	protectedRoutes.Use(middleware.AuthMiddleware(securityService, appLogger))
+
+	// Routes for other services, authenticated by client credentials rather than
+	// rate limited per IP since a few gateway instances make all the calls
+	serviceRoutes := router.Group("/auth")
+	serviceRoutes.Use(middleware.ServiceAuthMiddleware(cfg.Security.IntrospectionClients, appLogger))
+
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(
 		authService,
@@ -166,23 +362,42 @@ func Initialize() (*Container, error) {
 	// Health check handler
 	healthHandler := handler.NewHealthHandler(db, redisClient)
 
+	// Public keys for services verifying our tokens
+	jwksHandler := handler.NewJWKSHandler(keyRing)
+	discoveryHandler := handler.NewDiscoveryHandler(cfg.Security.TokenIssuer, cfg.Server.PublicBaseURL, keyRing)
+
+	// Development mailbox handler
+	var devMailboxHandler *handler.DevMailboxHandler
+	if devMailbox != nil {
+		devMailboxHandler = handler.NewDevMailboxHandler(devMailbox)
+	}
+
 	return &Container{
-		Config:     cfg,
-		Router:     router,
-		AuthRoutes: authRoutes,
-		Logger:     appLogger,
+		Config:          cfg,
+		Router:          router,
+		AuthRoutes:      authRoutes,
+		ProtectedRoutes: protectedRoutes,
+		ServiceRoutes:   serviceRoutes,
+		Logger:          appLogger,
 
 		// Services
 		AuthService:     authService,
 		OTPService:      otpService,
 		EmailService:    emailService,
+		SMSService:      smsService,
 		SecurityService: securityService,
 		MetricsService:  metricsService,
 		RedisService:    redisService,
 
+		// Background workers
+		EmailDispatcher: emailDispatcher,
+
 		// Handlers
-		AuthHandler:   authHandler,
-		HealthHandler: healthHandler,
+		AuthHandler:       authHandler,
+		HealthHandler:     healthHandler,
+		JWKSHandler:       jwksHandler,
+		DiscoveryHandler:  discoveryHandler,
+		DevMailboxHandler: devMailboxHandler,
 	}, nil
 }
 
@@ -190,6 +405,18 @@ func Initialize() (*Container, error) {
 func (c *Container) SetupRoutes() {
 	// Register health routes at the root level
 	c.HealthHandler.RegisterRoutes(c.Router)
+	c.JWKSHandler.RegisterRoutes(c.Router)
+	c.DiscoveryHandler.RegisterRoutes(c.Router)
 	// Register auth routes in the auth group
 	c.AuthHandler.RegisterRoutes(c.AuthRoutes)
+	c.AuthHandler.RegisterProtectedRoutes(c.ProtectedRoutes)
+	c.AuthHandler.RegisterServiceRoutes(c.ServiceRoutes)
+	// Development mailbox is never exposed outside development, and only to
+	// clients on the same machine
+	if c.DevMailboxHandler != nil {
+		devRoutes := c.Router.Group("")
+		devRoutes.Use(middleware.LoopbackOnlyMiddleware(c.Logger))
+		c.DevMailboxHandler.RegisterRoutes(devRoutes)
+		c.Logger.Warn("Development mailbox enabled at /dev/mailbox for loopback clients")
+	}
 }
@@ -3,7 +3,10 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,8 +14,10 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/middleware"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model/dto"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/response"
 )
@@ -45,6 +50,27 @@ func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/login", h.Login)
 	router.POST("/refresh-token", h.RefreshToken)
 	router.POST("/logout", h.Logout)
+	router.POST("/resend-otp", h.ResendOTP)
+	router.POST("/password/forgot", h.ForgotPassword)
+	router.POST("/password/reset", h.ResetPassword)
+}
+
+// RegisterProtectedRoutes registers the routes that need an access token,
+// router must already run the auth middleware
+func (h *AuthHandler) RegisterProtectedRoutes(router gin.IRoutes) {
+	router.POST("/verify-phone/send", h.SendPhoneVerification)
+	router.POST("/verify-phone/confirm", h.ConfirmPhoneVerification)
+	router.POST("/password/change", h.ChangePassword)
+	router.GET("/userinfo", h.UserInfo)
+	router.POST("/logout-all", h.LogoutAll)
+	router.GET("/sessions", h.ListSessions)
+	router.DELETE("/sessions/:id", h.RevokeSession)
+}
+
+// RegisterServiceRoutes registers the routes for other services, router must
+// already run the service auth middleware
+func (h *AuthHandler) RegisterServiceRoutes(router gin.IRoutes) {
+	router.POST("/introspect", h.Introspect)
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -65,7 +91,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	if err := c.ShouldBindJSON(&request); err != nil {
 		h.metricsService.IncRegistrationFailure(c, "invalid_request")
 		h.logger.RegistrationFailure("", clientIP, "invalid_request", zap.Error(err))
-		response.BadRequest(c, "Invalid request", err)
+		response.BadRequest(c, i18n.T(c, "request.invalid"), err)
 		return
 	}
 
@@ -73,11 +99,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	request.Email = h.securityService.SanitizeInput(c, request.Email)
 	request.Phone = h.securityService.SanitizeInput(c, request.Phone)
 
-	// Validate password strength
-	if isValid, reason := h.securityService.ValidatePassword(c, request.Password); !isValid {
+	// Validate password strength, passwords built from the email or phone are weak
+	if check := h.securityService.ValidatePassword(c, request.Password, request.Email, request.Phone); !check.Valid {
 		h.metricsService.IncRegistrationFailure(c, "weak_password")
-		h.logger.RegistrationFailure(request.Email, clientIP, reason)
-		response.BadRequest(c, "Password validation failed", &ValidationError{Reason: reason})
+		h.logger.RegistrationFailure(request.Email, clientIP, check.Reason)
+		respondInvalidPassword(c, check)
 		return
 	}
 
@@ -99,11 +125,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		case "email already exists", "phone already exists":
 			statusCode = http.StatusConflict
 			errorType = "duplicate_user"
-			errMsg = "User with this email or phone already exists"
+			errMsg = i18n.T(c, "register.duplicate_user")
 		default:
 			statusCode = http.StatusInternalServerError
 			errorType = "server_error"
-			errMsg = "Failed to process registration"
+			errMsg = i18n.T(c, "register.failed")
 		}
 
 		h.metricsService.IncRegistrationFailure(c, errorType)
@@ -117,20 +143,41 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	h.metricsService.IncRegistrationSuccess(c)
 
 	// Return standardized response
-	response.Created(c, "Registration successful. Please verify your email with the OTP sent.", gin.H{
+	response.Created(c, i18n.T(c, "register.success"), gin.H{
 		"id": resp.ID,
 	})
 }
 
 // ValidationError represents a password validation error
 type ValidationError struct {
-	Reason string
+	Reason      string
+	Score       int      // Estimated strength, 0-4
+	Suggestions []string // How to pick a stronger password
 }
 
 func (e *ValidationError) Error() string {
 	return e.Reason
 }
 
+// respondInvalidPassword answers a rejected password with the reason, the
+// estimated score and suggestions for a stronger one
+func respondInvalidPassword(c *gin.Context, check service.PasswordCheck) {
+	validationErr := &ValidationError{
+		Reason:      check.Reason,
+		Score:       check.Score,
+		Suggestions: check.Suggestions,
+	}
+	if validationErr.Suggestions == nil {
+		validationErr.Suggestions = []string{}
+	}
+
+	response.ErrorWithDetails(c, http.StatusBadRequest, i18n.T(c, "password.validation_failed"), validationErr, gin.H{
+		"score":       validationErr.Score,
+		"max_score":   service.MaxPasswordScore,
+		"suggestions": validationErr.Suggestions,
+	})
+}
+
 // internal/handler/auth_handler.go
 
 // internal/handler/auth_handler.go - Update VerifyEmail handler
@@ -165,7 +212,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		h.logger.VerificationFailure("", clientIP, "invalid_request",
 			h.logger.Field("error", err.Error()),
 			h.logger.Field("request_id", requestID))
-		response.BadRequest(c, "Invalid request", nil) // Don't expose validation errors
+		response.BadRequest(c, i18n.T(c, "request.invalid"), nil) // Don't expose validation errors
 		return
 	}
 
@@ -189,28 +236,31 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		// Map internal errors to user-friendly messages
 		// without exposing sensitive details
 		switch {
+		case strings.Contains(err.Error(), "too many OTP attempts"):
+			statusCode = http.StatusTooManyRequests
+			errorType = "too_many_attempts"
+			errorMsg = i18n.T(c, "verify_email.too_many_attempts")
 		case strings.Contains(err.Error(), "no pending registration") ||
-			strings.Contains(err.Error(), "OTP expired") ||
 			strings.Contains(err.Error(), "not found"):
 			statusCode = http.StatusNotFound
 			errorType = "verification_not_found"
-			errorMsg = "Verification request not found or expired"
+			errorMsg = i18n.T(c, "verify_email.not_found")
 		case strings.Contains(err.Error(), "invalid OTP"):
 			statusCode = http.StatusBadRequest
 			errorType = "invalid_otp"
-			errorMsg = "Invalid verification code"
+			errorMsg = i18n.T(c, "verify_email.invalid_otp")
 		case strings.Contains(err.Error(), "registration has expired"):
 			statusCode = http.StatusGone
 			errorType = "registration_expired"
-			errorMsg = "Registration has expired, please register again"
+			errorMsg = i18n.T(c, "verify_email.registration_expired")
 		case strings.Contains(err.Error(), "account already exists"):
 			statusCode = http.StatusConflict
 			errorType = "already_verified"
-			errorMsg = "This email is already verified"
+			errorMsg = i18n.T(c, "verify_email.already_verified")
 		default:
 			statusCode = http.StatusInternalServerError
 			errorType = "server_error"
-			errorMsg = "Failed to verify email"
+			errorMsg = i18n.T(c, "verify_email.failed")
 		}
 
 		h.metricsService.IncVerificationFailure(c, errorType)
@@ -234,7 +284,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 		h.logger.Field("duration_seconds", duration))
 
 	// Return standardized success response
-	response.Success(c, "Email verification successful", gin.H{
+	response.Success(c, i18n.T(c, "verify_email.success"), gin.H{
 		"id":    verifyResp.ID,
 		"email": verifyResp.Email,
 	})
@@ -260,8 +310,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	userAgent := c.Request.UserAgent()
 	requestID := uuid.New().String()
 
-	// Add request ID to context for tracing
+	// Add request ID to context for tracing, the service throttles by client IP
+	// and records the user agent with the session
 	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	ctx = context.WithValue(ctx, "user_agent", userAgent)
 	c.Request = c.Request.WithContext(ctx)
 
 	// Start metrics tracking
@@ -281,7 +334,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			h.logger.Field("error", err.Error()),
 			h.logger.Field("client_ip", clientIP),
 			h.logger.Field("request_id", requestID))
-		response.BadRequest(c, "Invalid request format", nil)
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
 		return
 	}
 
@@ -307,15 +360,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			strings.Contains(err.Error(), "invalid credentials"):
 			statusCode = http.StatusUnauthorized
 			errorType = "invalid_credentials"
-			errorMsg = "Invalid email or password"
+			errorMsg = i18n.T(c, "login.invalid_credentials")
 		case strings.Contains(err.Error(), "not verified"):
 			statusCode = http.StatusForbidden
 			errorType = "unverified_account"
-			errorMsg = "Email not verified. Please verify your email first"
+			errorMsg = i18n.T(c, "login.unverified")
+		case strings.Contains(err.Error(), "session limit reached"):
+			statusCode = http.StatusForbidden
+			errorType = "session_limit"
+			errorMsg = i18n.T(c, "login.session_limit_reached")
 		default:
 			statusCode = http.StatusInternalServerError
 			errorType = "server_error"
-			errorMsg = "Authentication failed"
+			errorMsg = i18n.T(c, "login.failed")
 		}
 
 		h.metricsService.IncLoginFailure(ctx, errorType)
@@ -337,7 +394,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		h.logger.Field("request_id", requestID))
 
 	// Return standardized response
-	response.Success(c, "Login successful", loginResp)
+	response.Success(c, i18n.T(c, "login.success"), loginResp)
 }
 
 // Add these methods to the AuthHandler
@@ -374,7 +431,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 			h.logger.Field("error", err.Error()),
 			h.logger.Field("client_ip", clientIP),
 			h.logger.Field("request_id", requestID))
-		response.BadRequest(c, "Invalid request format", nil)
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
 		return
 	}
 
@@ -397,15 +454,19 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 			strings.Contains(err.Error(), "expired"):
 			statusCode = http.StatusUnauthorized
 			errorType = "invalid_token"
-			errorMsg = "Refresh token is invalid or expired"
+			errorMsg = i18n.T(c, "token_refresh.invalid")
+		case strings.Contains(err.Error(), "client mismatch"):
+			statusCode = http.StatusUnauthorized
+			errorType = "client_mismatch"
+			errorMsg = i18n.T(c, "token_refresh.client_mismatch")
 		case strings.Contains(err.Error(), "revoked"):
 			statusCode = http.StatusUnauthorized
 			errorType = "token_revoked"
-			errorMsg = "Token has been revoked"
+			errorMsg = i18n.T(c, "token_refresh.revoked")
 		default:
 			statusCode = http.StatusInternalServerError
 			errorType = "server_error"
-			errorMsg = "Failed to refresh token"
+			errorMsg = i18n.T(c, "token_refresh.failed")
 		}
 
 		h.metricsService.IncTokenRefreshFailure(ctx, errorType)
@@ -425,7 +486,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		h.logger.Field("request_id", requestID))
 
 	// Return standardized response
-	response.Success(c, "Token refreshed successfully", refreshResp)
+	response.Success(c, i18n.T(c, "token_refresh.success"), refreshResp)
 }
 
 // Logout handles the logout endpoint
@@ -458,7 +519,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 			h.logger.Field("error", err.Error()),
 			h.logger.Field("client_ip", clientIP),
 			h.logger.Field("request_id", requestID))
-		response.BadRequest(c, "Invalid request format", nil)
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
 		return
 	}
 
@@ -477,7 +538,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 			h.logger.Field("client_ip", clientIP),
 			h.logger.Field("request_id", requestID))
 
-		response.Error(c, http.StatusUnauthorized, "Invalid tokens", nil)
+		response.Error(c, http.StatusUnauthorized, i18n.T(c, "logout.invalid_tokens"), nil)
 		return
 	}
 
@@ -488,5 +549,525 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		h.logger.Field("request_id", requestID))
 
 	// Return standardized response
-	response.Success(c, "Logged out successfully", nil)
+	response.Success(c, i18n.T(c, "logout.success"), nil)
+}
+
+// ResendOTP handles the verification OTP resend endpoint
+func (h *AuthHandler) ResendOTP(c *gin.Context) {
+	// Extract client info for logging
+	clientIP := c.ClientIP()
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request
+	var request dto.ResendOTPRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.Warn("OTP resend failure: invalid request",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	// Sanitize inputs
+	request.Email = h.securityService.SanitizeInput(ctx, request.Email)
+
+	// Resend the OTP
+	resendResp, err := h.authService.ResendOTP(ctx, &request)
+	if err != nil {
+		// Refused resends tell the client how long to wait
+		var resendErr *service.OTPResendError
+		if errors.As(err, &resendErr) {
+			retryAfter := int(math.Ceil(resendErr.RetryAfter.Seconds()))
+			errorMsg := i18n.T(c, "resend_otp.cooldown", retryAfter)
+			if resendErr.LimitReached {
+				errorMsg = i18n.T(c, "resend_otp.limit_reached")
+			}
+
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			response.ErrorWithDetails(c, http.StatusTooManyRequests, errorMsg, nil, gin.H{
+				"cooldown_seconds": retryAfter,
+			})
+			return
+		}
+
+		var statusCode int
+		var errorMsg string
+
+		// Map internal errors to user-friendly messages
+		switch {
+		case strings.Contains(err.Error(), "no pending registration"):
+			statusCode = http.StatusNotFound
+			errorMsg = i18n.T(c, "resend_otp.not_found")
+		case strings.Contains(err.Error(), "registration has expired"):
+			statusCode = http.StatusGone
+			errorMsg = i18n.T(c, "verify_email.registration_expired")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "resend_otp.failed")
+		}
+
+		h.logger.Warn("OTP resend failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	// Return standardized response
+	response.Success(c, resendResp.Message, gin.H{
+		"cooldown_seconds":  resendResp.CooldownSeconds,
+		"resends_remaining": resendResp.ResendsRemaining,
+	})
+}
+
+// SendPhoneVerification handles texting a phone verification OTP to the caller
+func (h *AuthHandler) SendPhoneVerification(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	sendResp, err := h.authService.SendPhoneVerification(ctx, userID)
+	if err != nil {
+		var resendErr *service.OTPResendError
+		if errors.As(err, &resendErr) {
+			retryAfter := int(math.Ceil(resendErr.RetryAfter.Seconds()))
+			errorMsg := i18n.T(c, "resend_otp.cooldown", retryAfter)
+			if resendErr.LimitReached {
+				errorMsg = i18n.T(c, "resend_otp.limit_reached")
+			}
+
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			response.ErrorWithDetails(c, http.StatusTooManyRequests, errorMsg, nil, gin.H{
+				"cooldown_seconds": retryAfter,
+			})
+			return
+		}
+
+		var statusCode int
+		var errorMsg string
+
+		// Map internal errors to user-friendly messages
+		switch {
+		case strings.Contains(err.Error(), "already verified"):
+			statusCode = http.StatusConflict
+			errorMsg = i18n.T(c, "verify_phone.already_verified")
+		case strings.Contains(err.Error(), "not found"):
+			statusCode = http.StatusUnauthorized
+			errorMsg = i18n.T(c, "auth.invalid_token")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "verify_phone.send_failed")
+		}
+
+		h.logger.Warn("Phone verification send failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	response.Success(c, sendResp.Message, gin.H{
+		"phone":              sendResp.Phone,
+		"expires_in_seconds": sendResp.ExpiresInSeconds,
+		"cooldown_seconds":   sendResp.CooldownSeconds,
+	})
+}
+
+// ConfirmPhoneVerification handles confirming the caller's phone number with the texted OTP
+func (h *AuthHandler) ConfirmPhoneVerification(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request
+	var request dto.ConfirmPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	// Sanitize inputs
+	request.OTP = h.securityService.SanitizeInput(ctx, request.OTP)
+
+	confirmResp, err := h.authService.ConfirmPhoneVerification(ctx, userID, &request)
+	if err != nil {
+		var statusCode int
+		var errorMsg string
+
+		// Map internal errors to user-friendly messages
+		switch {
+		case strings.Contains(err.Error(), "too many OTP attempts"):
+			statusCode = http.StatusTooManyRequests
+			errorMsg = i18n.T(c, "verify_phone.too_many_attempts")
+		case strings.Contains(err.Error(), "invalid OTP"):
+			statusCode = http.StatusBadRequest
+			errorMsg = i18n.T(c, "verify_phone.invalid_otp")
+		case strings.Contains(err.Error(), "already verified"):
+			statusCode = http.StatusConflict
+			errorMsg = i18n.T(c, "verify_phone.already_verified")
+		case strings.Contains(err.Error(), "not found"):
+			statusCode = http.StatusUnauthorized
+			errorMsg = i18n.T(c, "auth.invalid_token")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "verify_phone.failed")
+		}
+
+		h.logger.Warn("Phone verification failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	response.Success(c, confirmResp.Message, gin.H{
+		"phone_verified_at": confirmResp.PhoneVerifiedAt,
+	})
+}
+
+// ForgotPassword handles password reset requests. The answer never reveals
+// whether the email belongs to an account.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	clientIP := c.ClientIP()
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request
+	var request dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	// Sanitize inputs
+	request.Email = h.securityService.SanitizeInput(ctx, request.Email)
+
+	forgotResp, err := h.authService.ForgotPassword(ctx, &request)
+	if err != nil {
+		h.logger.Warn("Password reset request failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, http.StatusInternalServerError, i18n.T(c, "forgot_password.failed"), nil)
+		return
+	}
+
+	response.Success(c, forgotResp.Message, nil)
+}
+
+// ResetPassword handles setting a new password with a reset OTP
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	clientIP := c.ClientIP()
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request
+	var request dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	// Sanitize inputs
+	request.Email = h.securityService.SanitizeInput(ctx, request.Email)
+	request.OTP = h.securityService.SanitizeInput(ctx, request.OTP)
+
+	resetResp, err := h.authService.ResetPassword(ctx, &request)
+	if err != nil {
+		var passwordErr *service.PasswordValidationError
+		if errors.As(err, &passwordErr) {
+			respondInvalidPassword(c, passwordErr.Check)
+			return
+		}
+
+		var statusCode int
+		var errorMsg string
+
+		// Map internal errors to user-friendly messages
+		switch {
+		case strings.Contains(err.Error(), "too many OTP attempts"):
+			statusCode = http.StatusTooManyRequests
+			errorMsg = i18n.T(c, "reset_password.too_many_attempts")
+		case strings.Contains(err.Error(), "invalid OTP"):
+			statusCode = http.StatusBadRequest
+			errorMsg = i18n.T(c, "reset_password.invalid_otp")
+		case strings.Contains(err.Error(), "used recently"):
+			statusCode = http.StatusBadRequest
+			errorMsg = i18n.T(c, "reset_password.reused")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "reset_password.failed")
+		}
+
+		h.logger.Warn("Password reset failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	response.Success(c, resetResp.Message, nil)
+}
+
+// ChangePassword handles a logged-in user changing their password
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	tokenID := c.GetString(middleware.ContextKeyTokenID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request
+	var request dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	changeResp, err := h.authService.ChangePassword(ctx, userID, tokenID, &request)
+	if err != nil {
+		var passwordErr *service.PasswordValidationError
+		if errors.As(err, &passwordErr) {
+			respondInvalidPassword(c, passwordErr.Check)
+			return
+		}
+
+		var statusCode int
+		var errorMsg string
+
+		// Map internal errors to user-friendly messages
+		switch {
+		case strings.Contains(err.Error(), "invalid current password"):
+			statusCode = http.StatusBadRequest
+			errorMsg = i18n.T(c, "change_password.invalid_current_password")
+		case strings.Contains(err.Error(), "used recently"):
+			statusCode = http.StatusBadRequest
+			errorMsg = i18n.T(c, "password.reused")
+		case strings.Contains(err.Error(), "not found"):
+			statusCode = http.StatusUnauthorized
+			errorMsg = i18n.T(c, "auth.invalid_token")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "change_password.failed")
+		}
+
+		h.logger.Warn("Password change failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	response.Success(c, changeResp.Message, gin.H{
+		"revoked_sessions": changeResp.RevokedSessions,
+	})
+}
+
+// UserInfo returns the OpenID Connect standard claims of the token's user.
+// The claims are the response body itself, as OIDC clients expect.
+func (h *AuthHandler) UserInfo(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	userInfo, err := h.authService.GetUserInfo(ctx, userID)
+	if err != nil {
+		var statusCode int
+		var errorMsg string
+
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			statusCode = http.StatusUnauthorized
+			errorMsg = i18n.T(c, "auth.invalid_token")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "userinfo.failed")
+		}
+
+		h.logger.Warn("Userinfo failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userInfo)
+}
+
+// Introspect tells another service whether a token is valid (RFC 7662). Like
+// userinfo, the response body is the introspection result itself.
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	clientID := c.GetString(middleware.ContextKeyClientID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", c.ClientIP())
+	c.Request = c.Request.WithContext(ctx)
+
+	// Parse and validate request, form-encoded as in the RFC or JSON
+	var request dto.IntrospectionRequest
+	if err := c.ShouldBind(&request); err != nil {
+		response.BadRequest(c, i18n.T(c, "request.invalid_format"), nil)
+		return
+	}
+
+	result, err := h.authService.IntrospectToken(ctx, &request)
+	if err != nil {
+		h.logger.Warn("Token introspection failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("client_id", clientID),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, http.StatusInternalServerError, i18n.T(c, "introspection.failed"), nil)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, result)
+}
+
+// LogoutAll signs the caller out of every session, including this one
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	logoutResp, err := h.authService.LogoutAll(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Logout from all sessions failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, http.StatusInternalServerError, i18n.T(c, "logout_all.failed"), nil)
+		return
+	}
+
+	response.Success(c, logoutResp.Message, gin.H{
+		"revoked_sessions": logoutResp.RevokedSessions,
+	})
+}
+
+// ListSessions lists the caller's sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	tokenID := c.GetString(middleware.ContextKeyTokenID)
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	sessions, err := h.authService.ListSessions(ctx, userID, tokenID)
+	if err != nil {
+		h.logger.Warn("Session listing failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, http.StatusInternalServerError, i18n.T(c, "sessions.list_failed"), nil)
+		return
+	}
+
+	response.Success(c, i18n.T(c, "sessions.list_success"), sessions)
+}
+
+// RevokeSession signs out one of the caller's sessions
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	clientIP := c.ClientIP()
+	userID := c.GetString(middleware.ContextKeyUserID)
+	sessionID := c.Param("id")
+	requestID := uuid.New().String()
+
+	// Add request ID to context for tracing
+	ctx := context.WithValue(c.Request.Context(), "request_id", requestID)
+	ctx = context.WithValue(ctx, "client_ip", clientIP)
+	c.Request = c.Request.WithContext(ctx)
+
+	if err := h.authService.RevokeSession(ctx, userID, sessionID); err != nil {
+		var statusCode int
+		var errorMsg string
+
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			statusCode = http.StatusNotFound
+			errorMsg = i18n.T(c, "sessions.not_found")
+		default:
+			statusCode = http.StatusInternalServerError
+			errorMsg = i18n.T(c, "sessions.revoke_failed")
+		}
+
+		h.logger.Warn("Session revocation failure",
+			h.logger.Field("error", err.Error()),
+			h.logger.Field("user_id", userID),
+			h.logger.Field("session_id", sessionID),
+			h.logger.Field("client_ip", clientIP),
+			h.logger.Field("request_id", requestID))
+
+		response.Error(c, statusCode, errorMsg, nil)
+		return
+	}
+
+	response.Success(c, i18n.T(c, "sessions.revoked"), nil)
 }
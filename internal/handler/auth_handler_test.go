@@ -0,0 +1,386 @@
+// internal/handler/auth_handler_test.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/middleware"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model/dto"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// fakeAuthService fails every call with err, or answers with canned responses
+type fakeAuthService struct {
+	service.AuthService
+	err error
+}
+
+func (s *fakeAuthService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) (*dto.VerifyEmailResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.VerifyEmailResponse{ID: "user-1", Email: req.Email}, nil
+}
+
+func (s *fakeAuthService) ResendOTP(ctx context.Context, req *dto.ResendOTPRequest) (*dto.ResendOTPResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.ResendOTPResponse{Message: "sent", CooldownSeconds: 60, ResendsRemaining: 4}, nil
+}
+
+func (s *fakeAuthService) ConfirmPhoneVerification(ctx context.Context, userID string, req *dto.ConfirmPhoneVerificationRequest) (*dto.ConfirmPhoneVerificationResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.ConfirmPhoneVerificationResponse{Message: "verified", PhoneVerifiedAt: time.Now()}, nil
+}
+
+func (s *fakeAuthService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.ResetPasswordResponse{Message: "reset"}, nil
+}
+
+func (s *fakeAuthService) ChangePassword(ctx context.Context, userID, accessTokenID string, req *dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.ChangePasswordResponse{Message: "changed", RevokedSessions: 2}, nil
+}
+
+func (s *fakeAuthService) GetUserInfo(ctx context.Context, userID string) (*dto.UserInfoResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &dto.UserInfoResponse{Subject: userID, Email: "amal@example.com", EmailVerified: true}, nil
+}
+
+func (s *fakeAuthService) IntrospectToken(ctx context.Context, req *dto.IntrospectionRequest) (*dto.IntrospectionResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if req.Token != "live-token" {
+		return &dto.IntrospectionResponse{Active: false}, nil
+	}
+	return &dto.IntrospectionResponse{Active: true, Subject: "user-1", TokenType: dto.TokenTypeAccess}, nil
+}
+
+func (s *fakeAuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.err
+}
+
+// passThroughSecurityService leaves input as it is
+type passThroughSecurityService struct {
+	service.SecurityService
+}
+
+func (passThroughSecurityService) SanitizeInput(ctx context.Context, input string) string {
+	return input
+}
+
+// newTestAuthRouter serves the auth routes of authService, the protected
+// routes act as if user-1 signed in with access token token-1
+func newTestAuthRouter(authService service.AuthService) *gin.Engine {
+	h := NewAuthHandler(authService, passThroughSecurityService{}, service.NewNoOpMetricsService(), &logger.Logger{Logger: zap.NewNop()})
+
+	router := gin.New()
+	router.Use(middleware.LocaleMiddleware())
+	h.RegisterRoutes(router.Group("/auth"))
+	protected := router.Group("/auth")
+	protected.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, "user-1")
+		c.Set(middleware.ContextKeyTokenID, "token-1")
+	})
+	h.RegisterProtectedRoutes(protected)
+	h.RegisterServiceRoutes(router.Group("/auth"))
+	return router
+}
+
+// serveJSON sends body as JSON through router
+func serveJSON(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// assertResponse checks the status and, for errors, that the message is the
+// English translation of wantMessage
+func assertResponse(t *testing.T, w *httptest.ResponseRecorder, wantStatus int, wantMessage string, args ...interface{}) {
+	t.Helper()
+
+	if w.Code != wantStatus {
+		t.Fatalf("status = %d, want %d: %s", w.Code, wantStatus, w.Body)
+	}
+	if wantMessage == "" {
+		return
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response body %s: %v", w.Body, err)
+	}
+	if want := i18n.Translate(i18n.DefaultLocale, wantMessage, args...); body.Message != want {
+		t.Errorf("message = %q, want %q", body.Message, want)
+	}
+}
+
+func TestAuthHandlerResendOTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantMessage    string
+		wantArg        interface{}
+		wantRetryAfter string
+	}{
+		{name: "sent", wantStatus: http.StatusOK},
+		{name: "cooldown", err: &service.OTPResendError{RetryAfter: 41500 * time.Millisecond}, wantStatus: http.StatusTooManyRequests, wantMessage: "resend_otp.cooldown", wantArg: 42, wantRetryAfter: "42"},
+		{name: "daily limit", err: &service.OTPResendError{LimitReached: true, RetryAfter: 3 * time.Hour}, wantStatus: http.StatusTooManyRequests, wantMessage: "resend_otp.limit_reached", wantRetryAfter: "10800"},
+		{name: "no pending registration", err: errors.New("no pending registration found"), wantStatus: http.StatusNotFound, wantMessage: "resend_otp.not_found"},
+		{name: "registration expired", err: errors.New("registration has expired"), wantStatus: http.StatusGone, wantMessage: "verify_email.registration_expired"},
+		{name: "server error", err: errors.New("redis is down"), wantStatus: http.StatusInternalServerError, wantMessage: "resend_otp.failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodPost, "/auth/resend-otp", `{"email":"amal@example.com"}`)
+
+			var args []interface{}
+			if tt.wantArg != nil {
+				args = append(args, tt.wantArg)
+			}
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage, args...)
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if tt.wantRetryAfter != "" && !strings.Contains(w.Body.String(), `"cooldown_seconds":`+tt.wantRetryAfter) {
+				t.Errorf("body %s lacks cooldown_seconds %s", w.Body, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestAuthHandlerVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "verified", wantStatus: http.StatusOK, wantMessage: "verify_email.success"},
+		{name: "locked out", err: errors.New("too many OTP attempts"), wantStatus: http.StatusTooManyRequests, wantMessage: "verify_email.too_many_attempts"},
+		{name: "wrong code", err: errors.New("invalid OTP"), wantStatus: http.StatusBadRequest, wantMessage: "verify_email.invalid_otp"},
+		{name: "no pending registration", err: errors.New("no pending registration found"), wantStatus: http.StatusNotFound, wantMessage: "verify_email.not_found"},
+		{name: "registration expired", err: errors.New("registration has expired"), wantStatus: http.StatusGone, wantMessage: "verify_email.registration_expired"},
+		{name: "already verified", err: errors.New("account already exists"), wantStatus: http.StatusConflict, wantMessage: "verify_email.already_verified"},
+		{name: "server error", err: errors.New("database is down"), wantStatus: http.StatusInternalServerError, wantMessage: "verify_email.failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodPost, "/auth/verify-email", `{"email":"amal@example.com","otp":"123456"}`)
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+		})
+	}
+}
+
+func TestAuthHandlerConfirmPhoneVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "verified", body: `{"otp":"123456"}`, wantStatus: http.StatusOK},
+		{name: "locked out", body: `{"otp":"123456"}`, err: errors.New("too many OTP attempts"), wantStatus: http.StatusTooManyRequests, wantMessage: "verify_phone.too_many_attempts"},
+		{name: "wrong code", body: `{"otp":"123456"}`, err: errors.New("invalid OTP"), wantStatus: http.StatusBadRequest, wantMessage: "verify_phone.invalid_otp"},
+		{name: "already verified", body: `{"otp":"123456"}`, err: errors.New("phone already verified"), wantStatus: http.StatusConflict, wantMessage: "verify_phone.already_verified"},
+		{name: "user gone", body: `{"otp":"123456"}`, err: errors.New("user not found or inactive"), wantStatus: http.StatusUnauthorized, wantMessage: "auth.invalid_token"},
+		{name: "server error", body: `{"otp":"123456"}`, err: errors.New("database is down"), wantStatus: http.StatusInternalServerError, wantMessage: "verify_phone.failed"},
+		{name: "code missing", body: `{}`, wantStatus: http.StatusBadRequest, wantMessage: "request.invalid_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodPost, "/auth/verify-phone/confirm", tt.body)
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+		})
+	}
+}
+
+func TestAuthHandlerResetPassword(t *testing.T) {
+	const body = `{"email":"amal@example.com","otp":"12345678","new_password":"purple monkey dishwasher lamp"}`
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "reset", wantStatus: http.StatusOK},
+		{name: "locked out", err: errors.New("too many OTP attempts"), wantStatus: http.StatusTooManyRequests, wantMessage: "reset_password.too_many_attempts"},
+		{name: "wrong code", err: errors.New("invalid OTP"), wantStatus: http.StatusBadRequest, wantMessage: "reset_password.invalid_otp"},
+		{name: "reused password", err: errors.New("password was used recently"), wantStatus: http.StatusBadRequest, wantMessage: "reset_password.reused"},
+		{name: "weak password", err: &service.PasswordValidationError{Check: service.PasswordCheck{Reason: "too weak"}}, wantStatus: http.StatusBadRequest, wantMessage: "password.validation_failed"},
+		{name: "server error", err: errors.New("database is down"), wantStatus: http.StatusInternalServerError, wantMessage: "reset_password.failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodPost, "/auth/password/reset", body)
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+		})
+	}
+}
+
+func TestAuthHandlerChangePassword(t *testing.T) {
+	const body = `{"current_password":"correct horse battery staple","new_password":"purple monkey dishwasher lamp"}`
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "changed", wantStatus: http.StatusOK},
+		{name: "wrong current password", err: errors.New("invalid current password"), wantStatus: http.StatusBadRequest, wantMessage: "change_password.invalid_current_password"},
+		{name: "reused password", err: errors.New("password was used recently"), wantStatus: http.StatusBadRequest, wantMessage: "password.reused"},
+		{name: "weak password", err: &service.PasswordValidationError{Check: service.PasswordCheck{Reason: "too weak", Score: 1}}, wantStatus: http.StatusBadRequest, wantMessage: "password.validation_failed"},
+		{name: "user gone", err: errors.New("user not found or inactive"), wantStatus: http.StatusUnauthorized, wantMessage: "auth.invalid_token"},
+		{name: "server error", err: errors.New("database is down"), wantStatus: http.StatusInternalServerError, wantMessage: "change_password.failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodPost, "/auth/password/change", body)
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+		})
+	}
+}
+
+func TestAuthHandlerUserInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "claims", wantStatus: http.StatusOK},
+		{name: "user gone", err: errors.New("user not found or inactive"), wantStatus: http.StatusUnauthorized, wantMessage: "auth.invalid_token"},
+		{name: "server error", err: errors.New("database is down"), wantStatus: http.StatusInternalServerError, wantMessage: "userinfo.failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodGet, "/auth/userinfo", "")
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+			if tt.err != nil {
+				return
+			}
+
+			// The claims are the body itself, not wrapped in the standard response
+			var claims map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &claims); err != nil {
+				t.Fatalf("invalid userinfo body %s: %v", w.Body, err)
+			}
+			if claims["sub"] != "user-1" || claims["email"] != "amal@example.com" || claims["email_verified"] != true || claims["phone_number_verified"] != false {
+				t.Errorf("userinfo = %v, want the claims of user-1", claims)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+		})
+	}
+}
+
+func TestAuthHandlerIntrospect(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		err         error
+		wantStatus  int
+		wantActive  bool
+	}{
+		{name: "form encoded", contentType: "application/x-www-form-urlencoded", body: "token=live-token&token_type_hint=access_token", wantStatus: http.StatusOK, wantActive: true},
+		{name: "JSON", contentType: "application/json", body: `{"token":"live-token"}`, wantStatus: http.StatusOK, wantActive: true},
+		{name: "inactive token", contentType: "application/x-www-form-urlencoded", body: "token=revoked-token", wantStatus: http.StatusOK},
+		{name: "token missing", contentType: "application/x-www-form-urlencoded", body: "token_type_hint=access_token", wantStatus: http.StatusBadRequest},
+		{name: "revocation check unavailable", contentType: "application/x-www-form-urlencoded", body: "token=live-token", err: errors.New("failed to introspect token"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			req := httptest.NewRequest(http.MethodPost, "/auth/introspect", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("invalid introspection body %s: %v", w.Body, err)
+			}
+			if result["active"] != tt.wantActive {
+				t.Errorf("active = %v, want %v", result["active"], tt.wantActive)
+			}
+			if !tt.wantActive && len(result) != 1 {
+				t.Errorf("inactive token response = %v, want only active", result)
+			}
+		})
+	}
+}
+
+func TestAuthHandlerRevokeSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "revoked", wantStatus: http.StatusOK, wantMessage: "sessions.revoked"},
+		{name: "unknown session", err: errors.New("session not found"), wantStatus: http.StatusNotFound, wantMessage: "sessions.not_found"},
+		{name: "server error", err: fmt.Errorf("failed to revoke session"), wantStatus: http.StatusInternalServerError, wantMessage: "sessions.revoke_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestAuthRouter(&fakeAuthService{err: tt.err})
+			w := serveJSON(router, http.MethodDelete, "/auth/sessions/family-1", "")
+			assertResponse(t, w, tt.wantStatus, tt.wantMessage)
+		})
+	}
+}
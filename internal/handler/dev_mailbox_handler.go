@@ -0,0 +1,76 @@
+// internal/handler/dev_mailbox_handler.go
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/response"
+)
+
+// DevMailboxHandler exposes mail captured in development mode.
+// It must never be registered outside development, and only behind
+// middleware.LoopbackOnlyMiddleware.
+type DevMailboxHandler struct {
+	mailbox *service.DevMailbox
+}
+
+func NewDevMailboxHandler(mailbox *service.DevMailbox) *DevMailboxHandler {
+	return &DevMailboxHandler{
+		mailbox: mailbox,
+	}
+}
+
+// RegisterRoutes registers the development mailbox routes
+func (h *DevMailboxHandler) RegisterRoutes(router gin.IRouter) {
+	mailbox := router.Group("/dev/mailbox")
+	mailbox.GET("", h.ListMessages)
+	mailbox.GET("/latest-otp", h.LatestOTP)
+	mailbox.DELETE("", h.Clear)
+}
+
+// ListMessages lists captured messages, newest first, optionally filtered by ?email=
+func (h *DevMailboxHandler) ListMessages(c *gin.Context) {
+	messages := h.mailbox.Messages(c.Query("email"))
+
+	response.Success(c, i18n.T(c, "dev_mailbox.listed"), gin.H{
+		"count":    len(messages),
+		"messages": messages,
+	})
+}
+
+// LatestOTP returns the most recent OTP mailed to ?email=
+func (h *DevMailboxHandler) LatestOTP(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		response.BadRequest(c, i18n.T(c, "dev_mailbox.email_required"), nil)
+		return
+	}
+
+	msg, ok := h.mailbox.LatestOTP(email)
+	if !ok {
+		response.NotFound(c, i18n.T(c, "dev_mailbox.otp_not_found"), nil)
+		return
+	}
+
+	response.Success(c, i18n.T(c, "dev_mailbox.otp_found"), gin.H{
+		"email":       email,
+		"otp":         msg.OTP,
+		"template":    msg.Template,
+		"message_id":  msg.ID,
+		"captured_at": msg.CapturedAt,
+	})
+}
+
+// Clear removes all captured messages
+func (h *DevMailboxHandler) Clear(c *gin.Context) {
+	if err := h.mailbox.Clear(); err != nil {
+		response.Error(c, http.StatusInternalServerError, i18n.T(c, "dev_mailbox.clear_failed"), err)
+		return
+	}
+
+	response.Success(c, i18n.T(c, "dev_mailbox.cleared"), nil)
+}
@@ -0,0 +1,89 @@
+// internal/handler/dev_mailbox_handler_test.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/middleware"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve sends a request through router as if it came from remoteAddr
+func serve(router http.Handler, method, target, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDevMailboxHandler(t *testing.T) {
+	mailbox, err := service.NewDevMailbox(service.DevMailboxConfig{}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+	err = mailbox.Send(context.Background(), &service.EmailMessage{
+		To:       []string{"amal@example.com"},
+		Subject:  "Your verification code",
+		Template: service.EmailTemplateVerification,
+		Data:     map[string]interface{}{"OTP": "123456"},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	router := gin.New()
+	routes := router.Group("")
+	routes.Use(middleware.LoopbackOnlyMiddleware(&logger.Logger{Logger: zap.NewNop()}))
+	NewDevMailboxHandler(mailbox).RegisterRoutes(routes)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		remoteAddr string
+		wantStatus int
+		wantOTP    string
+	}{
+		{name: "latest OTP", method: http.MethodGet, target: "/dev/mailbox/latest-otp?email=amal@example.com", remoteAddr: "127.0.0.1:5000", wantStatus: http.StatusOK, wantOTP: "123456"},
+		{name: "latest OTP over IPv6 loopback", method: http.MethodGet, target: "/dev/mailbox/latest-otp?email=amal@example.com", remoteAddr: "[::1]:5000", wantStatus: http.StatusOK, wantOTP: "123456"},
+		{name: "no OTP captured", method: http.MethodGet, target: "/dev/mailbox/latest-otp?email=other@example.com", remoteAddr: "127.0.0.1:5000", wantStatus: http.StatusNotFound},
+		{name: "email missing", method: http.MethodGet, target: "/dev/mailbox/latest-otp", remoteAddr: "127.0.0.1:5000", wantStatus: http.StatusBadRequest},
+		{name: "list", method: http.MethodGet, target: "/dev/mailbox", remoteAddr: "127.0.0.1:5000", wantStatus: http.StatusOK},
+		{name: "remote client", method: http.MethodGet, target: "/dev/mailbox/latest-otp?email=amal@example.com", remoteAddr: "203.0.113.7:5000", wantStatus: http.StatusForbidden},
+		{name: "remote client clearing", method: http.MethodDelete, target: "/dev/mailbox", remoteAddr: "203.0.113.7:5000", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.target, tt.remoteAddr)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.target, w.Code, tt.wantStatus, w.Body)
+			}
+
+			var body struct {
+				Data struct {
+					OTP string `json:"otp"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid response body %s: %v", w.Body, err)
+			}
+			if body.Data.OTP != tt.wantOTP {
+				t.Errorf("otp = %q, want %q", body.Data.OTP, tt.wantOTP)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// internal/handler/discovery_handler.go
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+)
+
+// discoveryCacheControl lets clients cache the discovery document, it only
+// changes with a deployment
+const discoveryCacheControl = "public, max-age=3600"
+
+// DiscoveryHandler serves the OpenID Connect discovery document
+type DiscoveryHandler struct {
+	issuer  string
+	baseURL string
+	keyRing *service.JWTKeyRing
+}
+
+// NewDiscoveryHandler creates the discovery handler. baseURL is the public
+// URL of the service, which is never taken from the request: the document is
+// cached by shared caches and tells relying parties where to fetch keys.
+func NewDiscoveryHandler(issuer, baseURL string, keyRing *service.JWTKeyRing) *DiscoveryHandler {
+	return &DiscoveryHandler{
+		issuer:  issuer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		keyRing: keyRing,
+	}
+}
+
+// RegisterRoutes registers the discovery route at its well-known location
+func (h *DiscoveryHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/.well-known/openid-configuration", h.GetConfiguration)
+}
+
+// GetConfiguration returns the provider metadata (OpenID Connect Discovery 1.0).
+// Tokens are obtained from /auth/login with the user's credentials and
+// rotated at /auth/refresh-token, both take JSON bodies. There is no
+// authorization endpoint and no ID token, tokens are signed with the
+// algorithms of the JWKS keys.
+func (h *DiscoveryHandler) GetConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", discoveryCacheControl)
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                h.issuer,
+		"jwks_uri":                              h.baseURL + "/.well-known/jwks.json",
+		"token_endpoint":                        h.baseURL + "/auth/login",
+		"token_refresh_endpoint":                h.baseURL + "/auth/refresh-token",
+		"token_endpoint_auth_methods_supported": []string{"none"},
+		"grant_types_supported":                 []string{"password", "refresh_token"},
+		"userinfo_endpoint":                     h.baseURL + "/auth/userinfo",
+		"introspection_endpoint":                h.baseURL + "/auth/introspect",
+		"introspection_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+		"subject_types_supported":                       []string{"public"},
+		"access_token_signing_alg_values_supported":     h.keyRing.Algorithms(),
+		"claims_supported": []string{
+			"sub", "iss", "iat", "exp", "jti", "roles",
+			"email", "email_verified", "phone_number", "phone_number_verified",
+		},
+	})
+}
@@ -0,0 +1,82 @@
+// internal/handler/discovery_handler_test.go
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+)
+
+func TestDiscoveryHandlerGetConfiguration(t *testing.T) {
+	signingKey, err := service.NewHMACSigningKey("test-key", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("NewHMACSigningKey() error = %v", err)
+	}
+	keyRing, err := service.NewJWTKeyRing(signingKey)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing() error = %v", err)
+	}
+
+	// Mount the routes where the container does, the document must only point at them
+	router := gin.New()
+	NewDiscoveryHandler("https://auth.example.com", "https://auth.example.com/", keyRing).RegisterRoutes(router)
+	NewJWKSHandler(keyRing).RegisterRoutes(router)
+	authHandler := NewAuthHandler(nil, nil, nil, nil)
+	authHandler.RegisterRoutes(router.Group("/auth"))
+	authHandler.RegisterProtectedRoutes(router.Group("/auth"))
+	authHandler.RegisterServiceRoutes(router.Group("/auth"))
+
+	w := serve(router, http.MethodGet, "/.well-known/openid-configuration", "203.0.113.7:5000")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /.well-known/openid-configuration = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Cache-Control"); got != discoveryCacheControl {
+		t.Errorf("Cache-Control = %q, want %q", got, discoveryCacheControl)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("invalid discovery document %s: %v", w.Body, err)
+	}
+	if document["issuer"] != "https://auth.example.com" {
+		t.Errorf("issuer = %v, want https://auth.example.com", document["issuer"])
+	}
+	if algs, _ := document["access_token_signing_alg_values_supported"].([]interface{}); len(algs) != 1 || algs[0] != "HS256" {
+		t.Errorf("access_token_signing_alg_values_supported = %v, want [HS256]", algs)
+	}
+
+	routes := make(map[string]bool)
+	for _, route := range router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	tests := []struct {
+		field  string
+		method string
+		path   string
+	}{
+		{field: "jwks_uri", method: http.MethodGet, path: "/.well-known/jwks.json"},
+		{field: "token_endpoint", method: http.MethodPost, path: "/auth/login"},
+		{field: "token_refresh_endpoint", method: http.MethodPost, path: "/auth/refresh-token"},
+		{field: "userinfo_endpoint", method: http.MethodGet, path: "/auth/userinfo"},
+		{field: "introspection_endpoint", method: http.MethodPost, path: "/auth/introspect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			endpoint, _ := document[tt.field].(string)
+			parsed, err := url.Parse(endpoint)
+			if err != nil || parsed.Scheme != "https" || parsed.Host != "auth.example.com" || parsed.Path != tt.path {
+				t.Fatalf("%s = %q, want https://auth.example.com%s", tt.field, endpoint, tt.path)
+			}
+			if !routes[tt.method+" "+tt.path] {
+				t.Errorf("%s points at %s %s, which isn't a route", tt.field, tt.method, tt.path)
+			}
+		})
+	}
+}
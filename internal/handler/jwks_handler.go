@@ -0,0 +1,36 @@
+// internal/handler/jwks_handler.go
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+)
+
+// jwksCacheControl lets verifiers cache the key set briefly. A new key is
+// published as next well before it signs anything, so a cached copy never
+// misses the key of a valid token.
+const jwksCacheControl = "public, max-age=300"
+
+// JWKSHandler publishes the public keys tokens are verified with
+type JWKSHandler struct {
+	keyRing *service.JWTKeyRing
+}
+
+func NewJWKSHandler(keyRing *service.JWTKeyRing) *JWKSHandler {
+	return &JWKSHandler{
+		keyRing: keyRing,
+	}
+}
+
+// RegisterRoutes registers the JWKS route at its well-known location
+func (h *JWKSHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS returns the key set in JSON Web Key Set format (RFC 7517)
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksCacheControl)
+	c.JSON(http.StatusOK, h.keyRing.JWKS())
+}
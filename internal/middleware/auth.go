@@ -0,0 +1,90 @@
+// internal/middleware/auth.go
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/service"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/response"
+)
+
+// Context keys set for authenticated requests
+const (
+	ContextKeyUserID      = "user_id"
+	ContextKeyRoles       = "roles"
+	ContextKeyTokenID     = "token_id"
+	ContextKeyAccessToken = "access_token"
+)
+
+// AuthMiddleware requires a valid, non-revoked access token in the
+// Authorization header and stores the caller's identity in the gin and the
+// request context
+func AuthMiddleware(securityService service.SecurityService, logger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		token = strings.TrimSpace(token)
+		if !found || token == "" {
+			response.Error(c, http.StatusUnauthorized, i18n.T(c, "auth.missing_token"), nil)
+			c.Abort()
+			return
+		}
+
+		claims, err := securityService.ValidateJWT(c.Request.Context(), token)
+		if err != nil {
+			logger.Warn("Rejected access token",
+				logger.Field("error", err.Error()),
+				logger.Field("client_ip", c.ClientIP()),
+				logger.Field("path", c.FullPath()))
+			response.Error(c, http.StatusUnauthorized, i18n.T(c, "auth.invalid_token"), nil)
+			c.Abort()
+			return
+		}
+
+		// Refresh tokens are signed with the same key but only good for /auth/refresh-token
+		if typ, _ := claims["typ"].(string); typ == "refresh" {
+			logger.SecurityEvent("Refresh token used as access token",
+				logger.Field("client_ip", c.ClientIP()),
+				logger.Field("path", c.FullPath()))
+			response.Error(c, http.StatusUnauthorized, i18n.T(c, "auth.invalid_token"), nil)
+			c.Abort()
+			return
+		}
+
+		userID, _ := claims["sub"].(string)
+		tokenID, _ := claims["jti"].(string)
+		if userID == "" {
+			response.Error(c, http.StatusUnauthorized, i18n.T(c, "auth.invalid_token"), nil)
+			c.Abort()
+			return
+		}
+
+		var roles []string
+		if rawRoles, ok := claims["roles"].([]interface{}); ok {
+			for _, role := range rawRoles {
+				if r, ok := role.(string); ok {
+					roles = append(roles, r)
+				}
+			}
+		}
+
+		c.Set(ContextKeyUserID, userID)
+		c.Set(ContextKeyRoles, roles)
+		c.Set(ContextKeyTokenID, tokenID)
+		c.Set(ContextKeyAccessToken, token)
+
+		ctx := context.WithValue(c.Request.Context(), ContextKeyUserID, userID)
+		ctx = context.WithValue(ctx, ContextKeyRoles, roles)
+		ctx = context.WithValue(ctx, ContextKeyTokenID, tokenID)
+		ctx = context.WithValue(ctx, ContextKeyAccessToken, token)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+// internal/middleware/locale.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// LocaleMiddleware negotiates the response locale from the Accept-Language
+// header and stores it in both the gin and the request context
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+
+		c.Set(i18n.ContextKey, locale)
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+
+		c.Next()
+	}
+}
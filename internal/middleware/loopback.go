@@ -0,0 +1,32 @@
+// internal/middleware/loopback.go
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/response"
+)
+
+// LoopbackOnlyMiddleware rejects requests that don't come from the machine
+// itself. The connection's address is used rather than c.ClientIP(), which
+// trusts forwarding headers any client can set.
+func LoopbackOnlyMiddleware(logger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			logger.SecurityEvent("Rejected non-loopback request",
+				logger.Field("remote_addr", c.Request.RemoteAddr),
+				logger.Field("path", c.FullPath()))
+			response.Error(c, http.StatusForbidden, i18n.T(c, "request.loopback_only"), nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
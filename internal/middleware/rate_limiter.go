@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
 	"golang.org/x/time/rate"
 )
@@ -80,7 +81,7 @@ func RateLimiterMiddleware(config RateLimiterConfig, logger *logger.Logger) gin.
 
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"status":  false,
-				"message": "Too many requests, please try again later",
+				"message": i18n.T(c, "request.rate_limited"),
 				"error":   "Rate limit exceeded",
 			})
 			c.Abort()
@@ -0,0 +1,48 @@
+// internal/middleware/service_auth.go
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/response"
+)
+
+// ContextKeyClientID holds the ID of the service that authenticated a request
+const ContextKeyClientID = "client_id"
+
+// ServiceAuthMiddleware requires HTTP Basic credentials of one of the
+// configured service clients (client ID to secret). With no clients
+// configured every request is rejected.
+func ServiceAuthMiddleware(clients map[string]string, logger *logger.Logger) gin.HandlerFunc {
+	// Compare digests so the comparison takes the same time whatever the secret's length
+	digests := make(map[string][32]byte, len(clients))
+	for clientID, secret := range clients {
+		digests[clientID] = sha256.Sum256([]byte(secret))
+	}
+
+	return func(c *gin.Context) {
+		clientID, secret, ok := c.Request.BasicAuth()
+		expected, known := digests[clientID]
+		provided := sha256.Sum256([]byte(secret))
+
+		if !ok || !known || subtle.ConstantTimeCompare(expected[:], provided[:]) != 1 {
+			logger.SecurityEvent("Rejected service credentials",
+				logger.Field("client_id", clientID),
+				logger.Field("client_ip", c.ClientIP()),
+				logger.Field("path", c.FullPath()))
+			c.Header("WWW-Authenticate", `Basic realm="auth-service"`)
+			response.Error(c, http.StatusUnauthorized, i18n.T(c, "auth.invalid_client"), nil)
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyClientID, clientID)
+		c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+// internal/middleware/service_auth_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestServiceAuthMiddleware(t *testing.T) {
+	clients := map[string]string{
+		"profile-service": "profile-secret",
+		"chat-service":    "chat-secret",
+	}
+
+	tests := []struct {
+		name         string
+		clients      map[string]string
+		clientID     string
+		secret       string
+		noAuth       bool
+		wantStatus   int
+		wantClientID string
+	}{
+		{name: "known client", clients: clients, clientID: "profile-service", secret: "profile-secret", wantStatus: http.StatusOK, wantClientID: "profile-service"},
+		{name: "other known client", clients: clients, clientID: "chat-service", secret: "chat-secret", wantStatus: http.StatusOK, wantClientID: "chat-service"},
+		{name: "another client's secret", clients: clients, clientID: "profile-service", secret: "chat-secret", wantStatus: http.StatusUnauthorized},
+		{name: "secret prefix", clients: clients, clientID: "profile-service", secret: "profile", wantStatus: http.StatusUnauthorized},
+		{name: "unknown client", clients: clients, clientID: "billing-service", secret: "profile-secret", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", clients: clients, noAuth: true, wantStatus: http.StatusUnauthorized},
+		{name: "empty secret of an unknown client", clients: clients, clientID: "", secret: "", wantStatus: http.StatusUnauthorized},
+		{name: "no clients configured", clients: nil, clientID: "profile-service", secret: "profile-secret", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotClientID string
+			router := gin.New()
+			router.Use(ServiceAuthMiddleware(tt.clients, &logger.Logger{Logger: zap.NewNop()}))
+			router.POST("/auth/introspect", func(c *gin.Context) {
+				gotClientID = c.GetString(ContextKeyClientID)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/introspect", nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.clientID, tt.secret)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotClientID != tt.wantClientID {
+				t.Errorf("client ID = %q, want %q", gotClientID, tt.wantClientID)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header missing")
+			}
+		})
+	}
+}
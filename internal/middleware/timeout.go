@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
 )
 
@@ -46,7 +47,7 @@ func TimeoutMiddleware(timeout time.Duration, logger *logger.Logger) gin.Handler
 				c.Abort()
 				c.JSON(http.StatusGatewayTimeout, gin.H{
 					"status":  false,
-					"message": "Request timed out",
+					"message": i18n.T(c, "request.timeout"),
 					"error":   "The server took too long to process your request",
 				})
 			}
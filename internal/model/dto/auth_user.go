@@ -1,10 +1,14 @@
 package dto
 
+import "time"
+
 // Add a struct for registration request and response
 type RegistrationRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Phone    string `json:"phone" binding:"required"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required,min=8,max=128"`
+	// Locale is the preferred language for emails, defaults to Accept-Language
+	Locale string `json:"locale,omitempty"`
 }
 
 type RegistrationResponse struct {
@@ -25,6 +29,37 @@ type VerifyEmailResponse struct {
 	Message string `json:"message"`
 }
 
+// ResendOTPRequest represents the request to resend the verification OTP
+type ResendOTPRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResendOTPResponse represents the response after resending the verification OTP
+type ResendOTPResponse struct {
+	Message          string `json:"message"`
+	CooldownSeconds  int    `json:"cooldown_seconds"`  // Wait before another resend is allowed
+	ResendsRemaining int    `json:"resends_remaining"` // Resends left in the current 24 hour window
+}
+
+// SendPhoneVerificationResponse represents the response after texting a phone verification OTP
+type SendPhoneVerificationResponse struct {
+	Message          string `json:"message"`
+	Phone            string `json:"phone"`              // Masked phone number the OTP was sent to
+	ExpiresInSeconds int    `json:"expires_in_seconds"` // OTP lifetime
+	CooldownSeconds  int    `json:"cooldown_seconds"`   // Wait before another OTP can be sent
+}
+
+// ConfirmPhoneVerificationRequest represents the request to confirm a phone number with an OTP
+type ConfirmPhoneVerificationRequest struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+// ConfirmPhoneVerificationResponse represents the response after confirming a phone number
+type ConfirmPhoneVerificationResponse struct {
+	Message         string    `json:"message"`
+	PhoneVerifiedAt time.Time `json:"phone_verified_at"`
+}
+
 // LoginRequest represents the request for user login
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -0,0 +1,28 @@
+package dto
+
+// Token types reported by introspection
+const (
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
+// IntrospectionRequest represents a token introspection request (RFC 7662),
+// sent form-encoded or as JSON. TokenTypeHint is accepted but not needed,
+// the token type is read from the token itself.
+type IntrospectionRequest struct {
+	Token         string `form:"token" json:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint"`
+}
+
+// IntrospectionResponse describes a token. Only Active is set for a token
+// that is invalid, expired, revoked or belongs to an inactive user.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Subject   string   `json:"sub,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	TokenID   string   `json:"jti,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+}
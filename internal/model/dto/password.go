@@ -0,0 +1,36 @@
+package dto
+
+// ForgotPasswordRequest represents the request to start a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPasswordResponse represents the response to a password reset request,
+// it is the same whether or not the email belongs to an account
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ResetPasswordRequest represents the request to set a new password with a reset OTP
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	OTP         string `json:"otp" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=128"`
+}
+
+// ResetPasswordResponse represents the response after a password reset
+type ResetPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ChangePasswordRequest represents the request of a logged-in user to change their password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=128"`
+}
+
+// ChangePasswordResponse represents the response after a password change
+type ChangePasswordResponse struct {
+	Message         string `json:"message"`
+	RevokedSessions int    `json:"revoked_sessions"` // Other sessions that were signed out
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// SessionResponse describes one logged-in session of a user
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`      // e.g. "Chrome on Android"
+	DeviceType string    `json:"device_type"` // desktop, mobile, tablet, bot or unknown
+	ClientIP   string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"` // The session making the request
+}
+
+// ListSessionsResponse lists a user's sessions, most recently used first
+type ListSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
@@ -16,3 +16,9 @@ type LogoutRequest struct {
 	AccessToken  string `json:"access_token" binding:"required"`
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+// LogoutAllResponse represents the response after signing out everywhere
+type LogoutAllResponse struct {
+	Message         string `json:"message"`
+	RevokedSessions int    `json:"revoked_sessions"`
+}
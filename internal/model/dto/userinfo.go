@@ -0,0 +1,11 @@
+package dto
+
+// UserInfoResponse holds the OpenID Connect standard claims of the user an
+// access token was issued to
+type UserInfoResponse struct {
+	Subject             string `json:"sub"`
+	Email               string `json:"email"`
+	EmailVerified       bool   `json:"email_verified"`
+	PhoneNumber         string `json:"phone_number"`
+	PhoneNumberVerified bool   `json:"phone_number_verified"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Email outbox statuses
+const (
+	EmailOutboxStatusPending = "pending" // Waiting for its (next) delivery attempt
+	EmailOutboxStatusSent    = "sent"    // Accepted by the mail server
+	EmailOutboxStatusDead    = "dead"    // Gave up, needs manual attention
+)
+
+// EmailOutbox is an email queued for delivery in the same transaction as the
+// change that triggered it, and delivered later by the email dispatcher
+type EmailOutbox struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Recipient     string     `gorm:"type:varchar(255);not null" json:"recipient"`
+	Template      string     `gorm:"type:varchar(100);not null" json:"template"`
+	Locale        string     `gorm:"type:varchar(10);not null;default:en" json:"locale"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"-"`
+	Status        string     `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"not null" json:"updated_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// TableName overrides the pluralized table name
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+func (e *EmailOutbox) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package model
+
+// OTPPurpose scopes an OTP to the flow it was issued for. Every purpose has
+// its own key namespace, so a code issued for one flow never verifies in another.
+type OTPPurpose string
+
+// OTP purposes
+const (
+	OTPPurposeRegistration  OTPPurpose = "registration"   // Email verification of a pending registration
+	OTPPurposePasswordReset OTPPurpose = "password_reset" // Forgotten password
+	OTPPurposeEmailChange   OTPPurpose = "email_change"   // Confirming a new email address
+	OTPPurposeLogin         OTPPurpose = "login"          // One-time login code
+
+	OTPPurposePhoneVerification OTPPurpose = "phone_verification" // Confirming the phone number by SMS
+)
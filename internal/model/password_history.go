@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory is a hash of a password a user had before, kept so recent
+// passwords can't be reused
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
+	CreatedAt    time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName overrides the pluralized table name
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func (p *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
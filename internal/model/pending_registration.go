@@ -12,6 +12,7 @@ type PendingRegistration struct {
 	Email     string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
 	Phone     string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"phone"`
 	Password  string    `gorm:"type:varchar(255);not null" json:"-"`
+	Locale    string    `gorm:"type:varchar(10);not null;default:en" json:"locale"`
 	CreatedAt time.Time `gorm:"not null" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
 	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
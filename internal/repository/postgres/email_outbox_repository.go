@@ -0,0 +1,110 @@
+// internal/repository/postgres/email_outbox_repository.go
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	"gorm.io/gorm"
+)
+
+type EmailOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxRepository(db *gorm.DB) repository.EmailOutboxRepository {
+	return &EmailOutboxRepository{
+		db: db,
+	}
+}
+
+// Enqueue stores a message in the outbox, joining the caller's transaction if any
+func (r *EmailOutboxRepository) Enqueue(ctx context.Context, msg *model.EmailOutbox) error {
+	// Check if there's a transaction in the context
+	if tx, ok := ctx.Value("tx").(*gorm.DB); ok {
+		return tx.Create(msg).Error
+	}
+
+	return r.db.WithContext(ctx).Create(msg).Error
+}
+
+// ClaimDue locks up to limit due messages and pushes their next attempt
+// forward by lease, so other dispatchers skip them while they are being sent.
+// If the dispatcher dies mid-send the messages become due again after lease.
+func (r *EmailOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	var messages []model.EmailOutbox
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).Raw(`
+		UPDATE email_outbox
+		SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), now, model.EmailOutboxStatusPending, now, limit,
+	).Scan(&messages)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return messages, nil
+}
+
+// MarkSent records a successful delivery. The payload is cleared because it
+// may hold one-time codes that have no business staying in the database.
+func (r *EmailOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID, attempts int) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     model.EmailOutboxStatusSent,
+			"attempts":   attempts,
+			"payload":    "{}",
+			"last_error": "",
+			"sent_at":    now,
+			"updated_at": now,
+		}).Error
+}
+
+// MarkRetry records a failed attempt and schedules the next one
+func (r *EmailOutboxRepository) MarkRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+// MarkDead moves a message to the dead-letter state, it won't be retried.
+// The payload is cleared as in MarkSent, the codes it may hold have expired
+// by the time anyone follows up anyway.
+func (r *EmailOutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	return r.db.WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     model.EmailOutboxStatusDead,
+			"attempts":   attempts,
+			"payload":    "{}",
+			"last_error": lastError,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// CountByStatus returns the number of messages in the given status
+func (r *EmailOutboxRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.EmailOutbox{}).Where("status = ?", status).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,71 @@
+// internal/repository/postgres/password_history_repository.go
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) repository.PasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		db: db,
+	}
+}
+
+// Add stores a previous password hash, joining the caller's transaction if any
+func (r *PasswordHistoryRepository) Add(ctx context.Context, entry *model.PasswordHistory) error {
+	return r.conn(ctx).Create(entry).Error
+}
+
+// ListRecent returns up to limit of a user's previous password hashes, newest first
+func (r *PasswordHistoryRepository) ListRecent(ctx context.Context, userID uuid.UUID, limit int) ([]model.PasswordHistory, error) {
+	var entries []model.PasswordHistory
+	if limit <= 0 {
+		return entries, nil
+	}
+
+	err := r.conn(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Prune deletes all but the keep newest entries of a user
+func (r *PasswordHistoryRepository) Prune(ctx context.Context, userID uuid.UUID, keep int) error {
+	if keep < 0 {
+		keep = 0
+	}
+
+	return r.conn(ctx).Exec(`
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = ?
+			ORDER BY created_at DESC
+			LIMIT ?
+		)`,
+		userID, userID, keep,
+	).Error
+}
+
+// conn returns the transaction in ctx if there is one
+func (r *PasswordHistoryRepository) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value("tx").(*gorm.DB); ok {
+		return tx
+	}
+	return r.db.WithContext(ctx)
+}
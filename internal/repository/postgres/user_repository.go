@@ -183,6 +183,11 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.
 
 // UpdateUser updates user information
 func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	// Check if there's a transaction in the context
+	if tx, ok := ctx.Value("tx").(*gorm.DB); ok {
+		return tx.Save(user).Error
+	}
+
 	result := r.db.WithContext(ctx).Save(user)
 	return result.Error
 }
@@ -3,47 +3,129 @@ package redis
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
 	redisClient "github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/redis"
 )
 
 var (
-	ErrOTPNotFound      = errors.New("OTP not found or expired")
-	ErrRedisUnavailable = errors.New("Redis is unavailable")
+	ErrOTPNotFound        = errors.New("OTP not found or expired")
+	ErrRedisUnavailable   = errors.New("Redis is unavailable")
+	ErrTooManyOTPAttempts = errors.New("too many OTP attempts")
 )
 
+// The digest comparison itself happens in Go in constant time. These
+// scripts only act if the stored digest is still the one that was compared,
+// so a replaced or already consumed OTP is never deleted or counted against.
+
+// consumeOTPScript deletes a matched OTP. Only one of several concurrent
+// verifications of the same OTP gets 1 back.
+var consumeOTPScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+
+redis.call('DEL', KEYS[1], KEYS[2])
+return 1
+`)
+
+// failOTPScript counts a failed guess, the counter expires with the OTP.
+// Returns 0 while attempts remain, -1 when the OTP is gone or was replaced
+// and -2 when this failure used up the last attempt (the OTP is deleted).
+var failOTPScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return -1
+end
+
+local failures = redis.call('INCR', KEYS[2])
+if failures == 1 then
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('PEXPIRE', KEYS[2], ttl)
+	end
+end
+
+if failures >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1], KEYS[2])
+	return -2
+end
+
+return 0
+`)
+
+// reserveResendScript checks the daily counter and the cooldown and, when
+// both allow it, starts a new cooldown and counts the resend in one step.
+// Returns {allowed, wait in ms, remaining resends}.
+var reserveResendScript = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+local count = tonumber(redis.call('GET', KEYS[2]) or '0')
+if count >= limit then
+	return {0, redis.call('PTTL', KEYS[2]), 0}
+end
+
+local wait = redis.call('PTTL', KEYS[1])
+if wait > 0 then
+	return {0, wait, limit - count}
+end
+
+redis.call('SET', KEYS[1], '1', 'PX', ARGV[1])
+count = redis.call('INCR', KEYS[2])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+end
+
+return {1, tonumber(ARGV[1]), limit - count}
+`)
+
+// resendWindow is the length of the window the daily resend cap applies to
+const resendWindow = 24 * time.Hour
+
 type OTPRepository struct {
 	client *redisClient.Client
 	prefix string
+	secret []byte
 }
 
-// NewOTPRepository creates a new Redis-based OTP repository
-func NewOTPRepository(client *redisClient.Client) repository.OTPRepository {
+// NewOTPRepository creates a new Redis-based OTP repository. OTPs are never
+// stored in plaintext, only as an HMAC keyed by secret.
+func NewOTPRepository(client *redisClient.Client, secret string) repository.OTPRepository {
 	return &OTPRepository{
 		client: client,
 		prefix: "otp:",
+		secret: []byte(secret),
 	}
 }
 
-// StoreOTP stores an OTP with the given key and expiry
-func (r *OTPRepository) StoreOTP(ctx context.Context, key string, otp string, expiry time.Duration) error {
+// StoreOTP stores the digest of an OTP for a purpose and recipient with the
+// given expiry, a new OTP starts with a clean failed-attempt counter
+func (r *OTPRepository) StoreOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string, expiry time.Duration) error {
 	if r.client == nil || r.client.Client == nil {
 		return ErrRedisUnavailable
 	}
-	return r.client.Set(ctx, r.prefix+key, otp, expiry).Err()
+
+	codeKey := r.key(purpose, "code", recipient)
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, codeKey, r.digest(codeKey, otp), expiry)
+	pipe.Del(ctx, r.key(purpose, "attempts", recipient))
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-// GetOTP retrieves an OTP for the given key
-func (r *OTPRepository) GetOTP(ctx context.Context, key string) (string, error) {
+// GetOTP retrieves the OTP digest stored for a purpose and recipient
+func (r *OTPRepository) GetOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) (string, error) {
 	if r.client == nil || r.client.Client == nil {
 		return "", ErrRedisUnavailable
 	}
 
-	val, err := r.client.Get(ctx, r.prefix+key).Result()
+	val, err := r.client.Get(ctx, r.key(purpose, "code", recipient)).Result()
 	if err == redis.Nil {
 		return "", ErrOTPNotFound
 	}
@@ -53,21 +135,24 @@ func (r *OTPRepository) GetOTP(ctx context.Context, key string) (string, error)
 	return val, nil
 }
 
-// DeleteOTP deletes an OTP for the given key
-func (r *OTPRepository) DeleteOTP(ctx context.Context, key string) error {
+// DeleteOTP deletes the OTP for a purpose and recipient
+func (r *OTPRepository) DeleteOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) error {
 	if r.client == nil || r.client.Client == nil {
 		return ErrRedisUnavailable
 	}
-	return r.client.Del(ctx, r.prefix+key).Err()
+	return r.client.Del(ctx, r.key(purpose, "code", recipient), r.key(purpose, "attempts", recipient)).Err()
 }
 
-// VerifyOTP checks if the provided OTP matches the stored OTP
-func (r *OTPRepository) VerifyOTP(ctx context.Context, key string, otp string) (bool, error) {
+// VerifyOTP checks if the provided OTP matches the stored OTP. The OTP is
+// deleted on success (one-time use) and after maxAttempts failed guesses,
+// in which case ErrTooManyOTPAttempts is returned. A missing or expired OTP
+// is reported as a mismatch so callers can't tell the two apart.
+func (r *OTPRepository) VerifyOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string, maxAttempts int) (bool, error) {
 	if r.client == nil || r.client.Client == nil {
 		return false, ErrRedisUnavailable
 	}
 
-	storedOTP, err := r.GetOTP(ctx, key)
+	stored, err := r.GetOTP(ctx, purpose, recipient)
 	if err != nil {
 		if err == ErrOTPNotFound {
 			return false, nil
@@ -75,14 +160,70 @@ func (r *OTPRepository) VerifyOTP(ctx context.Context, key string, otp string) (
 		return false, err
 	}
 
-	if storedOTP == otp {
-		// Delete the OTP after successful verification (one-time use)
-		if err := r.DeleteOTP(ctx, key); err != nil {
-			// Log the error but don't fail the verification
-			// Consider implementing a retry mechanism
+	codeKey := r.key(purpose, "code", recipient)
+	keys := []string{codeKey, r.key(purpose, "attempts", recipient)}
+
+	if hmac.Equal([]byte(stored), []byte(r.digest(codeKey, otp))) {
+		consumed, err := consumeOTPScript.Run(ctx, r.client.Client, keys, stored).Int()
+		if err != nil {
+			return false, err
 		}
-		return true, nil
+		// Someone else verified (or replaced) this OTP in the meantime
+		return consumed == 1, nil
+	}
+
+	result, err := failOTPScript.Run(ctx, r.client.Client, keys, stored, maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	if result == -2 {
+		return false, ErrTooManyOTPAttempts
 	}
 
 	return false, nil
 }
+
+// ReserveResend enforces the resend cooldown and the daily cap for a purpose
+// and recipient. The daily window starts with the first resend and lasts 24 hours.
+func (r *OTPRepository) ReserveResend(ctx context.Context, purpose model.OTPPurpose, recipient string, cooldown time.Duration, dailyLimit int) (*repository.OTPResendStatus, error) {
+	if r.client == nil || r.client.Client == nil {
+		return nil, ErrRedisUnavailable
+	}
+
+	keys := []string{
+		r.key(purpose, "resend_cooldown", recipient),
+		r.key(purpose, "resend_count", recipient),
+	}
+	result, err := reserveResendScript.Run(ctx, r.client.Client, keys,
+		cooldown.Milliseconds(), dailyLimit, resendWindow.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &repository.OTPResendStatus{
+		Allowed:    result[0] == 1,
+		RetryAfter: time.Duration(result[1]) * time.Millisecond,
+		Remaining:  int(result[2]),
+	}
+	if !status.Allowed && status.Remaining == 0 {
+		status.LimitReached = true
+	}
+
+	return status, nil
+}
+
+// key builds the Redis key of one piece of OTP state, every purpose has its
+// own namespace: otp:<purpose>:<kind>:<recipient>
+func (r *OTPRepository) key(purpose model.OTPPurpose, kind, recipient string) string {
+	return r.prefix + string(purpose) + ":" + kind + ":" + recipient
+}
+
+// digest binds the OTP to the key it is stored under, which names the
+// purpose and recipient, so a digest copied elsewhere doesn't verify there
+func (r *OTPRepository) digest(codeKey, otp string) string {
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte(codeKey))
+	mac.Write([]byte{0})
+	mac.Write([]byte(otp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
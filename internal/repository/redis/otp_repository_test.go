@@ -0,0 +1,223 @@
+// internal/repository/redis/otp_repository_test.go
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	redisClient "github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/redis"
+)
+
+const testRecipient = "user@example.com"
+
+func newTestOTPRepository(t *testing.T, secret string) (*OTPRepository, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewOTPRepository(&redisClient.Client{Client: client}, secret).(*OTPRepository), server
+}
+
+func TestOTPRepositoryStoresDigest(t *testing.T) {
+	repo, server := newTestOTPRepository(t, "otp-secret")
+	ctx := context.Background()
+
+	if err := repo.StoreOTP(ctx, model.OTPPurposeRegistration, testRecipient, "123456", time.Minute); err != nil {
+		t.Fatalf("StoreOTP() error = %v", err)
+	}
+
+	stored, err := server.Get("otp:registration:code:" + testRecipient)
+	if err != nil {
+		t.Fatalf("OTP was not stored: %v", err)
+	}
+	if strings.Contains(stored, "123456") {
+		t.Errorf("stored value %q contains the plaintext OTP", stored)
+	}
+	if len(stored) != 64 {
+		t.Errorf("stored value %q is not a hex SHA-256 digest", stored)
+	}
+	if server.TTL("otp:registration:code:"+testRecipient) != time.Minute {
+		t.Errorf("OTP TTL = %v, want %v", server.TTL("otp:registration:code:"+testRecipient), time.Minute)
+	}
+}
+
+func TestOTPRepositoryDigestIsKeyed(t *testing.T) {
+	repo, _ := newTestOTPRepository(t, "otp-secret")
+	other, _ := newTestOTPRepository(t, "another-secret")
+	codeKey := repo.key(model.OTPPurposeRegistration, "code", testRecipient)
+
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{name: "other secret", got: other.digest(codeKey, "123456")},
+		{name: "other purpose", got: repo.digest(repo.key(model.OTPPurposePasswordReset, "code", testRecipient), "123456")},
+		{name: "other recipient", got: repo.digest(repo.key(model.OTPPurposeRegistration, "code", "other@example.com"), "123456")},
+	}
+
+	want := repo.digest(codeKey, "123456")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got == want {
+				t.Errorf("digest = %q, want it to differ from %q", tt.got, want)
+			}
+		})
+	}
+}
+
+func TestOTPRepositoryVerifyOTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		guesses     []string
+		maxAttempts int
+		wantValid   []bool
+		wantErr     []error
+		wantStored  bool
+	}{
+		{
+			name:        "correct code is consumed",
+			guesses:     []string{"123456", "123456"},
+			maxAttempts: 3,
+			wantValid:   []bool{true, false},
+			wantErr:     []error{nil, nil},
+		},
+		{
+			name:        "wrong code keeps the OTP",
+			guesses:     []string{"000000"},
+			maxAttempts: 3,
+			wantValid:   []bool{false},
+			wantErr:     []error{nil},
+			wantStored:  true,
+		},
+		{
+			name:        "correct code after a wrong one",
+			guesses:     []string{"000000", "123456"},
+			maxAttempts: 3,
+			wantValid:   []bool{false, true},
+			wantErr:     []error{nil, nil},
+		},
+		{
+			name:        "too many attempts delete the OTP",
+			guesses:     []string{"000000", "111111", "222222", "123456"},
+			maxAttempts: 3,
+			wantValid:   []bool{false, false, false, false},
+			wantErr:     []error{nil, nil, ErrTooManyOTPAttempts, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, server := newTestOTPRepository(t, "otp-secret")
+			ctx := context.Background()
+
+			if err := repo.StoreOTP(ctx, model.OTPPurposeRegistration, testRecipient, "123456", time.Minute); err != nil {
+				t.Fatalf("StoreOTP() error = %v", err)
+			}
+
+			for i, guess := range tt.guesses {
+				valid, err := repo.VerifyOTP(ctx, model.OTPPurposeRegistration, testRecipient, guess, tt.maxAttempts)
+				if valid != tt.wantValid[i] || err != tt.wantErr[i] {
+					t.Errorf("VerifyOTP(%q) #%d = %v, %v, want %v, %v", guess, i+1, valid, err, tt.wantValid[i], tt.wantErr[i])
+				}
+			}
+
+			if stored := server.Exists("otp:registration:code:" + testRecipient); stored != tt.wantStored {
+				t.Errorf("OTP still stored = %v, want %v", stored, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestOTPRepositoryVerifyOTPOtherPurpose(t *testing.T) {
+	repo, _ := newTestOTPRepository(t, "otp-secret")
+	ctx := context.Background()
+
+	if err := repo.StoreOTP(ctx, model.OTPPurposeRegistration, testRecipient, "123456", time.Minute); err != nil {
+		t.Fatalf("StoreOTP() error = %v", err)
+	}
+
+	valid, err := repo.VerifyOTP(ctx, model.OTPPurposePasswordReset, testRecipient, "123456", 3)
+	if valid || err != nil {
+		t.Errorf("VerifyOTP() for another purpose = %v, %v, want false, nil", valid, err)
+	}
+}
+
+func TestOTPRepositoryVerifyOTPConcurrently(t *testing.T) {
+	repo, _ := newTestOTPRepository(t, "otp-secret")
+	ctx := context.Background()
+
+	if err := repo.StoreOTP(ctx, model.OTPPurposeLogin, testRecipient, "123456", time.Minute); err != nil {
+		t.Fatalf("StoreOTP() error = %v", err)
+	}
+
+	const verifiers = 10
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < verifiers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			valid, err := repo.VerifyOTP(ctx, model.OTPPurposeLogin, testRecipient, "123456", 3)
+			if err != nil {
+				t.Errorf("VerifyOTP() error = %v", err)
+			}
+			if valid {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("OTP accepted %d times, want once", accepted)
+	}
+}
+
+func TestOTPRepositoryReserveResend(t *testing.T) {
+	repo, server := newTestOTPRepository(t, "otp-secret")
+	ctx := context.Background()
+	reserve := func() *repository.OTPResendStatus {
+		t.Helper()
+		status, err := repo.ReserveResend(ctx, model.OTPPurposeRegistration, testRecipient, time.Minute, 2)
+		if err != nil {
+			t.Fatalf("ReserveResend() error = %v", err)
+		}
+		return status
+	}
+
+	if got := reserve(); !got.Allowed || got.Remaining != 1 {
+		t.Errorf("first resend = %+v, want allowed with 1 remaining", got)
+	}
+	if got := reserve(); got.Allowed || got.LimitReached || got.RetryAfter <= 0 || got.RetryAfter > time.Minute {
+		t.Errorf("resend during cooldown = %+v, want a wait of up to a minute", got)
+	}
+
+	server.FastForward(time.Minute)
+	if got := reserve(); !got.Allowed || got.Remaining != 0 {
+		t.Errorf("resend after cooldown = %+v, want allowed with 0 remaining", got)
+	}
+
+	server.FastForward(time.Minute)
+	if got := reserve(); got.Allowed || !got.LimitReached {
+		t.Errorf("resend over the daily cap = %+v, want the limit reached", got)
+	}
+
+	server.FastForward(resendWindow)
+	if got := reserve(); !got.Allowed {
+		t.Errorf("resend after the daily window = %+v, want allowed", got)
+	}
+}
@@ -27,17 +27,63 @@ type UserRepository interface {
 	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
 }
 
-// OTPRepository interface for OTP storage
+// PasswordHistoryRepository interface for the previous password hashes of users
+type PasswordHistoryRepository interface {
+	// Add stores a previous password hash, within the transaction in ctx if there is one
+	Add(ctx context.Context, entry *model.PasswordHistory) error
+
+	// ListRecent returns up to limit of a user's previous password hashes, newest first
+	ListRecent(ctx context.Context, userID uuid.UUID, limit int) ([]model.PasswordHistory, error)
+
+	// Prune deletes all but the keep newest entries of a user
+	Prune(ctx context.Context, userID uuid.UUID, keep int) error
+}
+
+// OTPResendStatus is the outcome of reserving an OTP resend
+type OTPResendStatus struct {
+	Allowed      bool
+	LimitReached bool          // The daily cap is used up, as opposed to a cooldown
+	RetryAfter   time.Duration // Cooldown after an allowed resend, otherwise time until the next one is allowed
+	Remaining    int           // Resends left in the current daily window
+}
+
+// OTPRepository interface for OTP storage, every OTP belongs to a purpose
+// and a recipient (email address or phone number)
 type OTPRepository interface {
-	// StoreOTP stores an OTP with the given key and expiry
-	StoreOTP(ctx context.Context, key string, otp string, expiry time.Duration) error
+	// StoreOTP stores an OTP for a purpose and recipient with the given expiry
+	StoreOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string, expiry time.Duration) error
+
+	// GetOTP retrieves the stored OTP digest for a purpose and recipient
+	GetOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) (string, error)
+
+	// DeleteOTP deletes the OTP for a purpose and recipient
+	DeleteOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) error
+
+	// VerifyOTP checks if the provided OTP matches the stored OTP, invalidating
+	// it after maxAttempts failed guesses
+	VerifyOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string, maxAttempts int) (bool, error)
+
+	// ReserveResend atomically enforces the resend cooldown and daily cap for a purpose and recipient
+	ReserveResend(ctx context.Context, purpose model.OTPPurpose, recipient string, cooldown time.Duration, dailyLimit int) (*OTPResendStatus, error)
+}
+
+// EmailOutboxRepository interface for the transactional email outbox
+type EmailOutboxRepository interface {
+	// Enqueue stores a message, within the transaction in ctx if there is one
+	Enqueue(ctx context.Context, msg *model.EmailOutbox) error
+
+	// ClaimDue locks due pending messages for delivery for the lease duration
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.EmailOutbox, error)
+
+	// MarkSent marks a message as delivered
+	MarkSent(ctx context.Context, id uuid.UUID, attempts int) error
 
-	// GetOTP retrieves an OTP for the given key
-	GetOTP(ctx context.Context, key string) (string, error)
+	// MarkRetry records a failed attempt and schedules the next one
+	MarkRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error
 
-	// DeleteOTP deletes an OTP for the given key
-	DeleteOTP(ctx context.Context, key string) error
+	// MarkDead moves a message to the dead-letter state
+	MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error
 
-	// VerifyOTP checks if the provided OTP matches the stored OTP
-	VerifyOTP(ctx context.Context, key string, otp string) (bool, error)
+	// CountByStatus returns the number of messages in a status
+	CountByStatus(ctx context.Context, status string) (int64, error)
 }
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,38 +17,52 @@ import (
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/postgres"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/redis"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/useragent"
 )
 
 // Implementation of the AuthService interface
 type authService struct {
 	userRepo        repository.UserRepository
+	passwordHistory PasswordHistoryService
 	otpService      OTPService
 	emailService    EmailService
+	smsService      SMSService
 	securityService SecurityService
 	metricsService  MetricsService
 	logger          *logger.Logger
 	redisService    RedisService
+	sessionLimiter  SessionLimiter
+	refreshBinding  RefreshBindingConfig
 }
 
 // NewAuthService creates a new auth service instance
 func NewAuthService(
 	userRepo repository.UserRepository,
+	passwordHistory PasswordHistoryService,
 	otpService OTPService,
 	emailService EmailService,
+	smsService SMSService,
 	securityService SecurityService,
 	metricsService MetricsService,
 	logger *logger.Logger,
 	redisService RedisService,
+	sessionLimiter SessionLimiter,
+	refreshBinding RefreshBindingConfig,
 ) AuthService {
 	return &authService{
 		userRepo:        userRepo,
+		passwordHistory: passwordHistory,
 		otpService:      otpService,
 		emailService:    emailService,
+		smsService:      smsService,
 		securityService: securityService,
 		metricsService:  metricsService,
 		logger:          logger,
 		redisService:    redisService,
+		sessionLimiter:  sessionLimiter,
+		refreshBinding:  refreshBinding,
 	}
 }
 
@@ -87,6 +103,12 @@ func (s *authService) Register(ctx context.Context, req *dto.RegistrationRequest
 		return nil, errors.New("phone verification already in progress")
 	}
 
+	// Emails follow the explicitly chosen locale, otherwise the request's one
+	locale := i18n.Normalize(req.Locale)
+	if locale == "" {
+		locale = i18n.LocaleFromContext(ctx)
+	}
+
 	// Hash password
 	hashedPassword, err := s.securityService.HashPassword(ctx, req.Password)
 	if err != nil {
@@ -95,7 +117,7 @@ func (s *authService) Register(ctx context.Context, req *dto.RegistrationRequest
 
 	// Generate and store OTP for verification using Redis
 	// Use email as the key for the OTP
-	otp, err := s.otpService.GenerateAndStoreOTP(ctx, req.Email)
+	otp, err := s.otpService.GenerateAndStoreOTP(ctx, model.OTPPurposeRegistration, req.Email)
 	if err != nil {
 		// If it's a Redis connectivity issue, log it but continue
 		// In a production system, you might want a more robust fallback mechanism
@@ -112,6 +134,7 @@ func (s *authService) Register(ctx context.Context, req *dto.RegistrationRequest
 			Email:     req.Email,
 			Phone:     req.Phone,
 			Password:  hashedPassword,
+			Locale:    locale,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			ExpiresAt: time.Now().Add(24 * time.Hour), // Registration expires in 24 hours
@@ -122,6 +145,12 @@ func (s *authService) Register(ctx context.Context, req *dto.RegistrationRequest
 			return err
 		}
 
+		// Queue the verification email in the same transaction, so a committed
+		// registration always ends up with a delivered or dead-lettered email
+		if err := s.emailService.QueueVerificationEmail(i18n.WithLocale(txCtx, locale), req.Email, otp); err != nil {
+			return fmt.Errorf("failed to queue verification email: %w", err)
+		}
+
 		registrationID = registration.ID
 		return nil
 	})
@@ -130,16 +159,9 @@ func (s *authService) Register(ctx context.Context, req *dto.RegistrationRequest
 		return nil, err
 	}
 
-	// Send verification email
-	if err := s.emailService.SendVerificationEmail(ctx, req.Email, otp); err != nil {
-		// Log this error but don't fail the registration
-		// The user can request a new OTP later
-		// TODO: implement retry mechanism or queue
-	}
-
 	return &dto.RegistrationResponse{
 		ID:      registrationID.String(),
-		Message: "Registration successful. Please verify your email with the OTP sent.",
+		Message: i18n.T(ctx, "register.success"),
 	}, nil
 }
 
@@ -158,12 +180,17 @@ func (s *authService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailReque
 		s.logger.Field("ip", clientIP),
 		s.logger.Field("request_id", ctx.Value("request_id")))
 
-	// 1. Verify the OTP
-	isValid, err := s.otpService.VerifyOTP(ctx, req.Email, req.OTP)
+	// 1. Verify the OTP, a missing or expired one is just invalid
+	isValid, err := s.otpService.VerifyOTP(ctx, model.OTPPurposeRegistration, req.Email, req.OTP)
 	if err != nil {
-		if errors.Is(err, redis.ErrOTPNotFound) {
-			s.logger.VerificationFailure(req.Email, clientIP, "otp_expired")
-			return nil, errors.New("OTP expired or not found")
+		if errors.Is(err, redis.ErrTooManyOTPAttempts) {
+			s.logger.VerificationFailure(req.Email, clientIP, "too_many_attempts")
+			s.logger.SecurityEvent("OTP invalidated after too many failed attempts",
+				s.logger.Field("event", "otp_attempts_exceeded"),
+				s.logger.Field("email", req.Email),
+				s.logger.Field("ip", clientIP),
+				s.logger.Field("request_id", ctx.Value("request_id")))
+			return nil, errors.New("too many OTP attempts")
 		}
 		s.logger.VerificationFailure(req.Email, clientIP, "otp_verification_error",
 			s.logger.Field("error", err.Error()))
@@ -210,6 +237,7 @@ func (s *authService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailReque
 			IsActive:     true,
 			IsVerified:   true,
 			Role:         "user",
+			Locale:       pendingReg.Locale,
 			LastLoginAt:  time.Now(),
 		}
 
@@ -256,7 +284,7 @@ func (s *authService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailReque
 	return &dto.VerifyEmailResponse{
 		ID:      userID.String(),
 		Email:   req.Email,
-		Message: "Email verification successful",
+		Message: i18n.T(ctx, "verify_email.success"),
 	}, nil
 }
 
@@ -324,8 +352,20 @@ func (s *authService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 		return nil, errors.New("invalid credentials")
 	}
 
+	// Upgrade hashes made with an older algorithm or weaker parameters while
+	// the plaintext is at hand, saved with the last login time below
+	if s.securityService.PasswordNeedsRehash(ctx, user.PasswordHash) {
+		if newHash, err := s.securityService.HashPassword(ctx, req.Password); err != nil {
+			s.logger.Warn("Failed to rehash password",
+				s.logger.Field("user_id", user.ID.String()),
+				s.logger.Field("error", err.Error()))
+		} else {
+			user.PasswordHash = newHash
+		}
+	}
+
 	// Generate JWT token
-	accessToken, err := s.securityService.GenerateJWT(ctx, user.ID.String(), user.Role, user.LastLoginAt)
+	accessToken, accessTokenID, err := s.securityService.GenerateJWT(ctx, user.ID.String(), user.Role, user.LastLoginAt)
 	if err != nil {
 		s.logger.Error("Error generating JWT token",
 			s.logger.Field("user_id", user.ID.String()),
@@ -342,16 +382,29 @@ func (s *authService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 		return nil, errors.New("failed to generate refresh token")
 	}
 	// Store refresh token in Redis
+	now := time.Now()
 	tokenData := TokenData{
-		UserID:    user.ID.String(),
-		TokenID:   tokenID,
-		UserRole:  user.Role,
-		IssuedAt:  time.Now(),
-		UserAgent: userAgent,
-		ClientIP:  clientIP,
+		UserID:           user.ID.String(),
+		TokenID:          tokenID,
+		UserRole:         user.Role,
+		IssuedAt:         now,
+		UserAgent:        userAgent,
+		ClientIP:         clientIP,
+		AccessTokenID:    accessTokenID,
+		FamilyID:         uuid.New().String(),
+		SessionStartedAt: now,
 	}
 
-	if err := s.redisService.StoreRefreshToken(ctx, tokenID, refreshToken, tokenData); err != nil {
+	// Store the session within the role's session limit, only once its tokens
+	// exist so a failed login never costs the user another session
+	if _, err := s.sessionLimiter.StoreSession(ctx, tokenID, tokenData); err != nil {
+		if errors.Is(err, ErrSessionLimitReached) {
+			s.logger.Warn("Login refused by session limit",
+				s.logger.Field("user_id", user.ID.String()),
+				s.logger.Field("role", user.Role),
+				s.logger.Field("client_ip", clientIP))
+			return nil, err
+		}
 		s.logger.Error("Error storing refresh token",
 			s.logger.Field("user_id", user.ID.String()),
 			s.logger.Field("error", err.Error()))
@@ -370,7 +423,7 @@ func (s *authService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Lo
 	user.LastLoginAt = time.Now()
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		// Log error but continue - this shouldn't block login
-		s.logger.Warn("Failed to update last login time",
+		s.logger.Warn("Failed to update last login time and password hash",
 			s.logger.Field("user_id", user.ID.String()),
 			s.logger.Field("error", err.Error()))
 	}
@@ -399,8 +452,19 @@ func (s *authService) RefreshToken(ctx context.Context, req *dto.RefreshTokenReq
 		return nil, errors.New("failed to validate refresh token")
 	}
 
-	// Check if token exists
+	// Check if token exists, a token that was already rotated is being replayed
 	if tokenData == nil {
+		familyID, err := s.redisService.GetRotatedTokenFamily(ctx, tokenID)
+		if err != nil {
+			s.logger.Error("Error retrieving refresh token family",
+				s.logger.Field("token_id", tokenID),
+				s.logger.Field("error", err.Error()))
+			return nil, errors.New("failed to validate refresh token")
+		}
+		if familyID != "" {
+			s.revokeTokenFamily(ctx, familyID, tokenID)
+			return nil, errors.New("refresh token reuse detected, session revoked")
+		}
 		return nil, errors.New("refresh token not found or expired")
 	}
 
@@ -417,12 +481,59 @@ func (s *authService) RefreshToken(ctx context.Context, req *dto.RefreshTokenReq
 		return nil, errors.New("refresh token has been revoked")
 	}
 
-	// Delete the used refresh token (token rotation)
-	if err := s.redisService.DeleteRefreshToken(ctx, tokenID); err != nil {
+	// A logout from all devices covers refresh tokens stored while it ran
+	cutoff, err := s.redisService.GetTokensRevokedBefore(ctx, tokenData.UserID)
+	if err != nil {
+		s.logger.Error("Error checking token revocation cutoff",
+			s.logger.Field("user_id", tokenData.UserID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to validate refresh token")
+	}
+	if !cutoff.IsZero() && tokenData.IssuedAt.Before(cutoff) {
+		return nil, errors.New("refresh token has been revoked")
+	}
+
+	// Compare the caller with the client the session was started from. A
+	// rejected refresh leaves the token usable by its rightful owner.
+	userAgent, _ := ctx.Value("user_agent").(string)
+	clientIP, _ := ctx.Value("client_ip").(string)
+	if changed := s.refreshBinding.Mismatches(*tokenData, userAgent, clientIP); len(changed) > 0 {
+		s.logger.SecurityEvent("Refresh token used by a different client",
+			s.logger.Field("user_id", tokenData.UserID),
+			s.logger.Field("session_id", tokenData.SessionID()),
+			s.logger.Field("token_id", tokenID),
+			s.logger.Field("changed", changed),
+			s.logger.Field("issued_ip", tokenData.ClientIP),
+			s.logger.Field("ip", clientIP),
+			s.logger.Field("issued_user_agent", tokenData.UserAgent),
+			s.logger.Field("user_agent", userAgent),
+			s.logger.Field("policy", s.refreshBinding.Policy))
+
+		if s.refreshBinding.Policy == RefreshBindingReject {
+			return nil, errors.New("refresh token client mismatch")
+		}
+	}
+
+	// Tokens issued before families were tracked start one now
+	if tokenData.FamilyID == "" {
+		tokenData.FamilyID = uuid.New().String()
+	}
+	if tokenData.SessionStartedAt.IsZero() {
+		tokenData.SessionStartedAt = tokenData.IssuedAt
+	}
+
+	// Delete the used refresh token (token rotation). Only one request can
+	// consume it, a concurrent one using the same token counts as a replay.
+	consumed, err := s.redisService.ConsumeRefreshToken(ctx, *tokenData)
+	if err != nil {
 		s.logger.Error("Error deleting used refresh token",
 			s.logger.Field("token_id", tokenID),
 			s.logger.Field("error", err.Error()))
-		// Continue despite error
+		return nil, errors.New("failed to validate refresh token")
+	}
+	if !consumed {
+		s.revokeTokenFamily(ctx, tokenData.FamilyID, tokenID)
+		return nil, errors.New("refresh token reuse detected, session revoked")
 	}
 
 	// Get user to ensure they still exist and are active
@@ -440,7 +551,7 @@ func (s *authService) RefreshToken(ctx context.Context, req *dto.RefreshTokenReq
 	}
 
 	// Generate new access token
-	accessToken, err := s.securityService.GenerateJWT(ctx, userID, tokenData.UserRole, user.LastLoginAt)
+	accessToken, accessTokenID, err := s.securityService.GenerateJWT(ctx, userID, tokenData.UserRole, user.LastLoginAt)
 	if err != nil {
 		s.logger.Error("Error generating access token",
 			s.logger.Field("user_id", userID),
@@ -459,15 +570,22 @@ func (s *authService) RefreshToken(ctx context.Context, req *dto.RefreshTokenReq
 
 	// Store new refresh token
 	newTokenData := TokenData{
-		UserID:    userID,
-		TokenID:   newTokenID,
-		UserRole:  tokenData.UserRole,
-		IssuedAt:  time.Now(),
-		UserAgent: tokenData.UserAgent,
-		ClientIP:  tokenData.ClientIP,
+		UserID:           userID,
+		TokenID:          newTokenID,
+		UserRole:         tokenData.UserRole,
+		IssuedAt:         time.Now(),
+		UserAgent:        tokenData.UserAgent,
+		ClientIP:         tokenData.ClientIP,
+		AccessTokenID:    accessTokenID,
+		FamilyID:         tokenData.FamilyID,
+		SessionStartedAt: tokenData.SessionStartedAt,
 	}
 
-	if err := s.redisService.StoreRefreshToken(ctx, newTokenID, newRefreshToken, newTokenData); err != nil {
+	if err := s.redisService.StoreRefreshToken(ctx, newTokenID, newTokenData); err != nil {
+		// A replay of this token was detected while it was being rotated
+		if errors.Is(err, ErrTokenFamilyRevoked) {
+			return nil, errors.New("refresh token reuse detected, session revoked")
+		}
 		s.logger.Error("Error storing new refresh token",
 			s.logger.Field("user_id", userID),
 			s.logger.Field("error", err.Error()))
@@ -480,6 +598,31 @@ func (s *authService) RefreshToken(ctx context.Context, req *dto.RefreshTokenReq
 	}, nil
 }
 
+// revokeTokenFamily ends the session a replayed refresh token belongs to.
+// Either the replay or the last rotation came from someone who stole the
+// token, and there's no telling which, so the whole family goes.
+func (s *authService) revokeTokenFamily(ctx context.Context, familyID, replayedTokenID string) {
+	revoked, err := s.redisService.RevokeTokenFamily(ctx, familyID)
+	if err != nil {
+		s.logger.Error("Error revoking refresh token family",
+			s.logger.Field("family_id", familyID),
+			s.logger.Field("error", err.Error()))
+	}
+
+	// The family may already be gone, e.g. after logout or an earlier replay
+	var userID, revokedTokenID string
+	if revoked != nil {
+		userID, revokedTokenID = revoked.UserID, revoked.TokenID
+	}
+
+	s.logger.SecurityEvent("Refresh token reuse detected, token family revoked",
+		s.logger.Field("family_id", familyID),
+		s.logger.Field("token_id", replayedTokenID),
+		s.logger.Field("user_id", userID),
+		s.logger.Field("revoked_token_id", revokedTokenID),
+		s.logger.Field("ip", getClientIP(ctx)))
+}
+
 // Add Logout method
 func (s *authService) Logout(ctx context.Context, req *dto.LogoutRequest) error {
 	// Extract token ID from access token
@@ -523,3 +666,726 @@ func (s *authService) Logout(ctx context.Context, req *dto.LogoutRequest) error
 
 	return nil
 }
+
+// LogoutAll signs a user out everywhere. Access tokens can't be enumerated,
+// so every token issued up to now is revoked by time, then the refresh
+// tokens are deleted.
+func (s *authService) LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error) {
+	if err := s.redisService.SetTokensRevokedBefore(ctx, userID, time.Now()); err != nil {
+		s.logger.Error("Error revoking tokens",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to log out all sessions")
+	}
+
+	revoked, err := s.redisService.DeleteAllRefreshTokens(ctx, userID)
+	if err != nil {
+		// The refresh tokens are already rejected by the cutoff
+		s.logger.Error("Error deleting refresh tokens",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+	}
+
+	s.logger.SecurityEvent("User logged out of all sessions",
+		s.logger.Field("user_id", userID),
+		s.logger.Field("revoked_sessions", revoked),
+		s.logger.Field("ip", getClientIP(ctx)))
+
+	return &dto.LogoutAllResponse{
+		Message:         i18n.T(ctx, "logout_all.success"),
+		RevokedSessions: revoked,
+	}, nil
+}
+
+// ResendOTP issues a new verification OTP for a pending registration, subject
+// to the per-email resend cooldown and daily cap
+func (s *authService) ResendOTP(ctx context.Context, req *dto.ResendOTPRequest) (*dto.ResendOTPResponse, error) {
+	clientIP := getClientIP(ctx)
+
+	pendingReg, err := s.userRepo.GetPendingRegistrationByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Error("Error retrieving pending registration for OTP resend",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error retrieving pending registration")
+	}
+
+	if pendingReg == nil {
+		return nil, errors.New("no pending registration found")
+	}
+
+	if time.Now().After(pendingReg.ExpiresAt) {
+		return nil, errors.New("registration has expired")
+	}
+
+	// Reserve the resend before generating anything, so a refused request
+	// leaves the current OTP valid
+	status, err := s.otpService.ReserveResend(ctx, model.OTPPurposeRegistration, req.Email)
+	if err != nil {
+		var resendErr *OTPResendError
+		if errors.As(err, &resendErr) {
+			s.logger.Warn("OTP resend refused",
+				s.logger.Field("email", req.Email),
+				s.logger.Field("ip", clientIP),
+				s.logger.Field("reason", resendErr.Error()),
+				s.logger.Field("retry_after_seconds", int(resendErr.RetryAfter.Seconds())))
+			return nil, resendErr
+		}
+		s.logger.Error("Error reserving OTP resend",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error resending OTP")
+	}
+
+	// The new OTP replaces the previous one
+	otp, err := s.otpService.GenerateAndStoreOTP(ctx, model.OTPPurposeRegistration, req.Email)
+	if err != nil {
+		s.logger.Error("Error generating OTP for resend",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error resending OTP")
+	}
+
+	locale := pendingReg.Locale
+	if locale == "" {
+		locale = i18n.LocaleFromContext(ctx)
+	}
+
+	if err := s.emailService.QueueVerificationEmail(i18n.WithLocale(ctx, locale), req.Email, otp); err != nil {
+		s.logger.Error("Error queueing verification email for resend",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error resending OTP")
+	}
+
+	s.logger.Info("Verification OTP resent",
+		s.logger.Field("email", req.Email),
+		s.logger.Field("ip", clientIP),
+		s.logger.Field("resends_remaining", status.Remaining))
+
+	return &dto.ResendOTPResponse{
+		Message:          i18n.T(ctx, "resend_otp.success"),
+		CooldownSeconds:  int(math.Ceil(status.RetryAfter.Seconds())),
+		ResendsRemaining: status.Remaining,
+	}, nil
+}
+
+// SendPhoneVerification texts a phone verification OTP to the user's phone
+// number. Sends share the OTP resend cooldown and daily cap.
+func (s *authService) SendPhoneVerification(ctx context.Context, userID string) (*dto.SendPhoneVerificationResponse, error) {
+	clientIP := getClientIP(ctx)
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error finding user for phone verification",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to validate user")
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, errors.New("user not found or inactive")
+	}
+
+	if user.PhoneVerifiedAt != nil {
+		return nil, errors.New("phone already verified")
+	}
+
+	policy, err := s.otpService.GetPolicy(model.OTPPurposePhoneVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	// Texts cost money and are a favourite abuse target, so every send is throttled
+	status, err := s.otpService.ReserveResend(ctx, model.OTPPurposePhoneVerification, user.Phone)
+	if err != nil {
+		var resendErr *OTPResendError
+		if errors.As(err, &resendErr) {
+			s.logger.Warn("Phone verification SMS refused",
+				s.logger.Field("user_id", userID),
+				s.logger.Field("ip", clientIP),
+				s.logger.Field("reason", resendErr.Error()))
+			return nil, resendErr
+		}
+		s.logger.Error("Error reserving phone verification SMS",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to send verification SMS")
+	}
+
+	otp, err := s.otpService.GenerateAndStoreOTP(ctx, model.OTPPurposePhoneVerification, user.Phone)
+	if err != nil {
+		s.logger.Error("Error generating phone verification OTP",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to send verification SMS")
+	}
+
+	message := i18n.Translate(user.Locale, "sms.phone_verification", otp, int(policy.Expiry.Minutes()))
+	if err := s.smsService.SendSMS(ctx, user.Phone, message); err != nil {
+		s.logger.Error("Error sending phone verification SMS",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to send verification SMS")
+	}
+
+	s.logger.Info("Phone verification SMS sent",
+		s.logger.Field("user_id", userID),
+		s.logger.Field("ip", clientIP))
+
+	return &dto.SendPhoneVerificationResponse{
+		Message:          i18n.T(ctx, "verify_phone.sent"),
+		Phone:            maskPhone(user.Phone),
+		ExpiresInSeconds: int(policy.Expiry.Seconds()),
+		CooldownSeconds:  int(math.Ceil(status.RetryAfter.Seconds())),
+	}, nil
+}
+
+// ConfirmPhoneVerification checks the texted OTP and marks the phone verified
+func (s *authService) ConfirmPhoneVerification(ctx context.Context, userID string, req *dto.ConfirmPhoneVerificationRequest) (*dto.ConfirmPhoneVerificationResponse, error) {
+	clientIP := getClientIP(ctx)
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error finding user for phone verification",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to validate user")
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, errors.New("user not found or inactive")
+	}
+
+	if user.PhoneVerifiedAt != nil {
+		return nil, errors.New("phone already verified")
+	}
+
+	isValid, err := s.otpService.VerifyOTP(ctx, model.OTPPurposePhoneVerification, user.Phone, req.OTP)
+	if err != nil {
+		if errors.Is(err, redis.ErrTooManyOTPAttempts) {
+			s.logger.SecurityEvent("OTP invalidated after too many failed attempts",
+				s.logger.Field("event", "otp_attempts_exceeded"),
+				s.logger.Field("purpose", string(model.OTPPurposePhoneVerification)),
+				s.logger.Field("user_id", userID),
+				s.logger.Field("ip", clientIP))
+			return nil, errors.New("too many OTP attempts")
+		}
+		s.logger.Error("Error verifying phone OTP",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error verifying OTP")
+	}
+
+	if !isValid {
+		return nil, errors.New("invalid OTP")
+	}
+
+	verifiedAt := time.Now()
+	user.PhoneVerifiedAt = &verifiedAt
+	user.UpdatedAt = verifiedAt
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Error marking phone verified",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to verify phone")
+	}
+
+	s.logger.Info("Phone verified",
+		s.logger.Field("user_id", userID),
+		s.logger.Field("ip", clientIP))
+
+	return &dto.ConfirmPhoneVerificationResponse{
+		Message:         i18n.T(ctx, "verify_phone.success"),
+		PhoneVerifiedAt: verifiedAt,
+	}, nil
+}
+
+// ForgotPassword emails a password reset OTP to an active account. The
+// response is the same whether or not the email is registered, so failures
+// after the lookup are only logged.
+func (s *authService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) (*dto.ForgotPasswordResponse, error) {
+	clientIP := getClientIP(ctx)
+	response := &dto.ForgotPasswordResponse{
+		Message: i18n.T(ctx, "forgot_password.success"),
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Error("Error finding user for password reset",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to process password reset")
+	}
+
+	if user == nil || !user.IsActive {
+		s.logger.Info("Password reset requested for unknown or inactive account",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("ip", clientIP))
+		return response, nil
+	}
+
+	policy, err := s.otpService.GetPolicy(model.OTPPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	// A refused request keeps the OTP that was already sent valid
+	if _, err := s.otpService.ReserveResend(ctx, model.OTPPurposePasswordReset, user.Email); err != nil {
+		var resendErr *OTPResendError
+		if errors.As(err, &resendErr) {
+			s.logger.Warn("Password reset email refused",
+				s.logger.Field("user_id", user.ID.String()),
+				s.logger.Field("ip", clientIP),
+				s.logger.Field("reason", resendErr.Error()))
+			return response, nil
+		}
+		s.logger.Error("Error reserving password reset email",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return response, nil
+	}
+
+	otp, err := s.otpService.GenerateAndStoreOTP(ctx, model.OTPPurposePasswordReset, user.Email)
+	if err != nil {
+		s.logger.Error("Error generating password reset OTP",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return response, nil
+	}
+
+	locale := user.Locale
+	if locale == "" {
+		locale = i18n.LocaleFromContext(ctx)
+	}
+
+	if err := s.emailService.QueuePasswordResetEmail(i18n.WithLocale(ctx, locale), user.Email, otp, int(policy.Expiry.Minutes())); err != nil {
+		s.logger.Error("Error queueing password reset email",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return response, nil
+	}
+
+	s.logger.Info("Password reset OTP sent",
+		s.logger.Field("user_id", user.ID.String()),
+		s.logger.Field("ip", clientIP))
+
+	return response, nil
+}
+
+// ResetPassword sets a new password after checking the reset OTP and signs
+// the user out everywhere by revoking all of their tokens
+func (s *authService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error) {
+	clientIP := getClientIP(ctx)
+
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Error("Error finding user for password reset",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to reset password")
+	}
+
+	// Validate the new password before the OTP is used up, with the same
+	// inputs whether or not the account exists so the answer doesn't tell
+	validation := s.securityService.ValidatePassword(ctx, req.NewPassword, req.Email)
+	if !validation.Valid {
+		return nil, &PasswordValidationError{Check: validation}
+	}
+
+	isValid, err := s.otpService.VerifyOTP(ctx, model.OTPPurposePasswordReset, req.Email, req.OTP)
+	if err != nil {
+		if errors.Is(err, redis.ErrTooManyOTPAttempts) {
+			s.logger.SecurityEvent("OTP invalidated after too many failed attempts",
+				s.logger.Field("event", "otp_attempts_exceeded"),
+				s.logger.Field("purpose", string(model.OTPPurposePasswordReset)),
+				s.logger.Field("email", req.Email),
+				s.logger.Field("ip", clientIP))
+			return nil, errors.New("too many OTP attempts")
+		}
+		s.logger.Error("Error verifying password reset OTP",
+			s.logger.Field("email", req.Email),
+			s.logger.Field("error", err.Error()))
+		return nil, fmt.Errorf("error verifying OTP")
+	}
+
+	if !isValid {
+		return nil, errors.New("invalid OTP")
+	}
+
+	// The OTP is only issued to active accounts, but the account may have changed since
+	if user == nil || !user.IsActive {
+		return nil, errors.New("invalid OTP")
+	}
+
+	// Checked only after the OTP, otherwise anyone could test guesses of old
+	// passwords, or of whether an email is registered
+	if user.Phone != "" {
+		if check := s.securityService.ValidatePassword(ctx, req.NewPassword, req.Email, user.Phone); !check.Valid {
+			return nil, &PasswordValidationError{Check: check}
+		}
+	}
+	if err := s.checkPasswordReuse(ctx, user, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	changedAt, err := s.updatePassword(ctx, user, req.NewPassword)
+	if err != nil {
+		return nil, errors.New("failed to reset password")
+	}
+
+	// Whoever knew the old password must not keep a session. Access tokens
+	// can't be enumerated, so they are revoked by time as on logout-all.
+	if err := s.redisService.SetTokensRevokedBefore(ctx, user.ID.String(), time.Now()); err != nil {
+		s.logger.Error("Error revoking tokens after password reset",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+	}
+
+	revoked, err := s.redisService.DeleteAllRefreshTokens(ctx, user.ID.String())
+	if err != nil {
+		s.logger.Error("Error revoking refresh tokens after password reset",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+	}
+
+	s.logger.SecurityEvent("Password reset",
+		s.logger.Field("event", "password_reset"),
+		s.logger.Field("user_id", user.ID.String()),
+		s.logger.Field("ip", clientIP),
+		s.logger.Field("revoked_sessions", revoked))
+
+	locale := user.Locale
+	if locale == "" {
+		locale = i18n.LocaleFromContext(ctx)
+	}
+
+	if err := s.emailService.QueuePasswordChangedEmail(i18n.WithLocale(ctx, locale), user.Email, changedAt); err != nil {
+		s.logger.Error("Error queueing password changed email",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+	}
+
+	return &dto.ResetPasswordResponse{
+		Message: i18n.T(ctx, "reset_password.success"),
+	}, nil
+}
+
+// ChangePassword replaces the password of a logged-in user after checking the
+// current one. Every other session is revoked, the session whose access token
+// made the call (accessTokenID) keeps its refresh token.
+func (s *authService) ChangePassword(ctx context.Context, userID, accessTokenID string, req *dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error) {
+	clientIP := getClientIP(ctx)
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error finding user for password change",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to validate user")
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, errors.New("user not found or inactive")
+	}
+
+	if !s.securityService.VerifyPassword(ctx, user.PasswordHash, req.CurrentPassword) {
+		s.logger.SecurityEvent("Password change with wrong current password",
+			s.logger.Field("event", "password_change_failed"),
+			s.logger.Field("user_id", userID),
+			s.logger.Field("ip", clientIP))
+		// Add delay to prevent timing attacks
+		time.Sleep(300 * time.Millisecond)
+		return nil, errors.New("invalid current password")
+	}
+
+	if check := s.securityService.ValidatePassword(ctx, req.NewPassword, user.Email, user.Phone); !check.Valid {
+		return nil, &PasswordValidationError{Check: check}
+	}
+
+	if err := s.checkPasswordReuse(ctx, user, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	changedAt, err := s.updatePassword(ctx, user, req.NewPassword)
+	if err != nil {
+		return nil, errors.New("failed to change password")
+	}
+
+	revoked, err := s.revokeOtherSessions(ctx, userID, accessTokenID)
+	if err != nil {
+		s.logger.Error("Error revoking sessions after password change",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+	}
+
+	s.logger.SecurityEvent("Password changed",
+		s.logger.Field("event", "password_changed"),
+		s.logger.Field("user_id", userID),
+		s.logger.Field("ip", clientIP),
+		s.logger.Field("revoked_sessions", revoked))
+
+	locale := user.Locale
+	if locale == "" {
+		locale = i18n.LocaleFromContext(ctx)
+	}
+
+	if err := s.emailService.QueuePasswordChangedEmail(i18n.WithLocale(ctx, locale), user.Email, changedAt); err != nil {
+		s.logger.Error("Error queueing password changed email",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+	}
+
+	return &dto.ChangePasswordResponse{
+		Message:         i18n.T(ctx, "change_password.success"),
+		RevokedSessions: revoked,
+	}, nil
+}
+
+// GetUserInfo returns the standard claims of an active user
+func (s *authService) GetUserInfo(ctx context.Context, userID string) (*dto.UserInfoResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error finding user for userinfo",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to load user")
+	}
+
+	if user == nil || !user.IsActive {
+		return nil, errors.New("user not found or inactive")
+	}
+
+	return &dto.UserInfoResponse{
+		Subject:             user.ID.String(),
+		Email:               user.Email,
+		EmailVerified:       user.IsVerified,
+		PhoneNumber:         user.Phone,
+		PhoneNumberVerified: user.PhoneVerifiedAt != nil,
+	}, nil
+}
+
+// ListSessions lists the sessions of a user, one per live refresh token
+func (s *authService) ListSessions(ctx context.Context, userID, accessTokenID string) (*dto.ListSessionsResponse, error) {
+	tokens, err := s.redisService.GetUserRefreshTokens(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error listing refresh tokens",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to list sessions")
+	}
+
+	sessions := make([]dto.SessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		client := useragent.Parse(token.UserAgent)
+		createdAt := token.SessionStartedAt
+		if createdAt.IsZero() {
+			createdAt = token.IssuedAt
+		}
+
+		sessions = append(sessions, dto.SessionResponse{
+			ID:         token.SessionID(),
+			Device:     client.String(),
+			DeviceType: client.Device,
+			ClientIP:   token.ClientIP,
+			CreatedAt:  createdAt,
+			LastUsedAt: token.IssuedAt,
+			Current:    accessTokenID != "" && token.AccessTokenID == accessTokenID,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return &dto.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// RevokeSession deletes a session's refresh token and blacklists its latest
+// access token, so the session ends immediately
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	tokens, err := s.redisService.GetUserRefreshTokens(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error listing refresh tokens",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return errors.New("failed to revoke session")
+	}
+
+	// Only the user's own sessions can be found, an ID from another account is simply unknown
+	for _, token := range tokens {
+		if token.SessionID() != sessionID {
+			continue
+		}
+
+		if err := s.redisService.RevokeRefreshToken(ctx, token); err != nil {
+			s.logger.Error("Error revoking session",
+				s.logger.Field("user_id", userID),
+				s.logger.Field("session_id", sessionID),
+				s.logger.Field("error", err.Error()))
+			return errors.New("failed to revoke session")
+		}
+
+		s.logger.SecurityEvent("Session revoked by user",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("session_id", sessionID),
+			s.logger.Field("ip", getClientIP(ctx)))
+		return nil
+	}
+
+	return errors.New("session not found")
+}
+
+// IntrospectToken checks a token the way this service would before accepting
+// it: signature, expiry and blacklist, for refresh tokens that they haven't
+// been used or revoked yet, and that the user is still active. An error is
+// only returned when the answer can't be determined.
+func (s *authService) IntrospectToken(ctx context.Context, req *dto.IntrospectionRequest) (*dto.IntrospectionResponse, error) {
+	inactive := &dto.IntrospectionResponse{Active: false}
+
+	claims, err := s.securityService.ValidateJWT(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, ErrRevocationCheckUnavailable) {
+			s.logger.Error("Error checking token blacklist during introspection",
+				s.logger.Field("error", err.Error()))
+			return nil, errors.New("failed to introspect token")
+		}
+		return inactive, nil
+	}
+
+	userID, _ := claims["sub"].(string)
+	tokenID, _ := claims["jti"].(string)
+	if userID == "" {
+		return inactive, nil
+	}
+
+	result := &dto.IntrospectionResponse{
+		Active:    true,
+		Subject:   userID,
+		TokenID:   tokenID,
+		TokenType: dto.TokenTypeAccess,
+	}
+	result.Issuer, _ = claims["iss"].(string)
+	if exp, ok := claims["exp"].(float64); ok {
+		result.ExpiresAt = int64(exp)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		result.IssuedAt = int64(iat)
+	}
+
+	if typ, _ := claims["typ"].(string); typ == "refresh" {
+		// Refresh tokens are single use, once rotated or revoked they are gone from Redis
+		tokenData, err := s.redisService.GetRefreshTokenData(ctx, tokenID)
+		if err != nil {
+			s.logger.Error("Error retrieving refresh token data during introspection",
+				s.logger.Field("token_id", tokenID),
+				s.logger.Field("error", err.Error()))
+			return nil, errors.New("failed to introspect token")
+		}
+		if tokenData == nil || tokenData.UserID != userID {
+			return inactive, nil
+		}
+		result.TokenType = dto.TokenTypeRefresh
+		result.Roles = []string{tokenData.UserRole}
+	} else if rawRoles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range rawRoles {
+			if r, ok := role.(string); ok {
+				result.Roles = append(result.Roles, r)
+			}
+		}
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Error finding user during introspection",
+			s.logger.Field("user_id", userID),
+			s.logger.Field("error", err.Error()))
+		return nil, errors.New("failed to introspect token")
+	}
+	if user == nil || !user.IsActive {
+		return inactive, nil
+	}
+
+	return result, nil
+}
+
+// checkPasswordReuse rejects the user's current and recent previous passwords
+func (s *authService) checkPasswordReuse(ctx context.Context, user *model.User, password string) error {
+	reused, err := s.passwordHistory.IsReused(ctx, user, password)
+	if err != nil {
+		s.logger.Error("Error checking password history",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return errors.New("failed to check password history")
+	}
+
+	if reused {
+		return errors.New("password was used recently")
+	}
+
+	return nil
+}
+
+// updatePassword stores the hash of a new password and moves the old hash to
+// the password history in one transaction
+func (s *authService) updatePassword(ctx context.Context, user *model.User, password string) (time.Time, error) {
+	hashedPassword, err := s.securityService.HashPassword(ctx, password)
+	if err != nil {
+		s.logger.Error("Error hashing new password",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return time.Time{}, err
+	}
+
+	previousHash := user.PasswordHash
+	changedAt := time.Now()
+	user.PasswordHash = hashedPassword
+	user.UpdatedAt = changedAt
+
+	err = s.userRepo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.userRepo.Update(txCtx, user); err != nil {
+			return err
+		}
+		return s.passwordHistory.Record(txCtx, user.ID, previousHash)
+	})
+	if err != nil {
+		user.PasswordHash = previousHash
+		s.logger.Error("Error updating password",
+			s.logger.Field("user_id", user.ID.String()),
+			s.logger.Field("error", err.Error()))
+		return time.Time{}, err
+	}
+
+	return changedAt, nil
+}
+
+// revokeOtherSessions revokes the refresh tokens of a user, and blacklists
+// the access tokens issued with them, except the one issued together with the
+// access token keepAccessTokenID
+func (s *authService) revokeOtherSessions(ctx context.Context, userID, keepAccessTokenID string) (int, error) {
+	tokens, err := s.redisService.GetUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, token := range tokens {
+		if keepAccessTokenID != "" && token.AccessTokenID == keepAccessTokenID {
+			continue
+		}
+		if err := s.redisService.RevokeRefreshToken(ctx, token); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// maskPhone hides all but the last 4 digits of a phone number
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
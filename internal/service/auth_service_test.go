@@ -0,0 +1,821 @@
+// internal/service/auth_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model/dto"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// fakeUserRepository keeps users in memory, handing out copies like the
+// database does
+type fakeUserRepository struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]model.User
+}
+
+func newFakeUserRepository(users ...*model.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: make(map[uuid.UUID]model.User)}
+	for _, user := range users {
+		r.users[user.ID] = *user
+	}
+	return r
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		return &user, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUserRepository) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return fn(ctx)
+}
+
+// user returns the stored copy of a user
+func (r *fakeUserRepository) user(id uuid.UUID) model.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[id]
+}
+
+// fakePasswordHistoryRepository keeps previous password hashes in memory
+type fakePasswordHistoryRepository struct {
+	mu      sync.Mutex
+	entries []model.PasswordHistory
+}
+
+func (r *fakePasswordHistoryRepository) Add(ctx context.Context, entry *model.PasswordHistory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.ID = uuid.New()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *fakePasswordHistoryRepository) ListRecent(ctx context.Context, userID uuid.UUID, limit int) ([]model.PasswordHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recent []model.PasswordHistory
+	for i := len(r.entries) - 1; i >= 0 && len(recent) < limit; i-- {
+		if r.entries[i].UserID == userID {
+			recent = append(recent, r.entries[i])
+		}
+	}
+	return recent, nil
+}
+
+func (r *fakePasswordHistoryRepository) Prune(ctx context.Context, userID uuid.UUID, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := 0
+	var entries []model.PasswordHistory
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if r.entries[i].UserID == userID {
+			if kept == keep {
+				continue
+			}
+			kept++
+		}
+		entries = append([]model.PasswordHistory{r.entries[i]}, entries...)
+	}
+	r.entries = entries
+	return nil
+}
+
+// fakeSMSService records the texts it is asked to send
+type fakeSMSService struct {
+	mu   sync.Mutex
+	sent map[string][]string // Messages by phone number
+	err  error
+}
+
+func (s *fakeSMSService) SendSMS(ctx context.Context, to string, message string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent == nil {
+		s.sent = make(map[string][]string)
+	}
+	s.sent[to] = append(s.sent[to], message)
+	return nil
+}
+
+// fakeEmailQueue records the emails the auth service queues
+type fakeEmailQueue struct {
+	EmailService
+	mu             sync.Mutex
+	resetOTPs      map[string]string // Password reset OTP by recipient
+	passwordChange []string          // Recipients told their password changed
+}
+
+func (q *fakeEmailQueue) QueuePasswordResetEmail(ctx context.Context, to string, otp string, expiryMins int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.resetOTPs == nil {
+		q.resetOTPs = make(map[string]string)
+	}
+	q.resetOTPs[to] = otp
+	return nil
+}
+
+func (q *fakeEmailQueue) QueuePasswordChangedEmail(ctx context.Context, to string, changedAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.passwordChange = append(q.passwordChange, to)
+	return nil
+}
+
+// testAuth is an auth service over in-memory users, a real Redis stand-in
+// and a security service signing with an HMAC key
+type testAuth struct {
+	service  AuthService
+	users    *fakeUserRepository
+	history  *fakePasswordHistoryRepository
+	redis    *redisService
+	security *securityService
+	otp      OTPService
+	sms      *fakeSMSService
+	emails   *fakeEmailQueue
+}
+
+func newTestAuthService(t *testing.T, binding RefreshBindingConfig, users ...*model.User) *testAuth {
+	t.Helper()
+
+	config := testOTPConfig()
+	config.Policies[model.OTPPurposePhoneVerification] = OTPPolicy{Length: 6, Expiry: 10 * time.Minute, MaxAttempts: 3}
+	otpService, _ := newTestOTPService(t, config)
+
+	redisService, _ := newTestRedisService(t)
+	securityService := newTestSecurityService(t, testHMACKeyRing(t), redisService)
+	history := &fakePasswordHistoryRepository{}
+
+	a := &testAuth{
+		users:    newFakeUserRepository(users...),
+		history:  history,
+		redis:    redisService,
+		security: securityService,
+		otp:      otpService,
+		sms:      &fakeSMSService{},
+		emails:   &fakeEmailQueue{},
+	}
+	a.service = NewAuthService(
+		a.users,
+		NewPasswordHistoryService(history, securityService, 2),
+		otpService,
+		a.emails,
+		a.sms,
+		securityService,
+		NewNoOpMetricsService(),
+		&logger.Logger{Logger: zap.NewNop()},
+		redisService,
+		newTestSessionLimiter(t, redisService, SessionLimitPolicyEvict),
+		binding,
+	)
+	return a
+}
+
+// testUser returns an active, verified user whose password is password
+func testUser(t *testing.T, password string) *model.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+
+	return &model.User{
+		ID:           uuid.New(),
+		Email:        "amal@example.com",
+		Phone:        "+919876543210",
+		PasswordHash: string(hash),
+		Role:         "user",
+		IsVerified:   true,
+		IsActive:     true,
+		Locale:       "en",
+	}
+}
+
+// login signs user in from a client and returns the tokens
+func (a *testAuth) login(t *testing.T, user *model.User, password, userAgent, clientIP string) *dto.LoginResponse {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), "client_ip", clientIP)
+	ctx = context.WithValue(ctx, "user_agent", userAgent)
+	resp, err := a.service.Login(ctx, &dto.LoginRequest{Email: user.Email, Password: password})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	return resp
+}
+
+func TestAuthServiceConfirmPhoneVerification(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		verified      bool // Phone already verified
+		inactive      bool
+		wrongGuesses  int
+		useOTP        bool // Confirm with the real code instead of a wrong one
+		wantErr       string
+		wantConfirmed bool
+	}{
+		{name: "right code", useOTP: true, wantConfirmed: true},
+		{name: "wrong code", wantErr: "invalid OTP"},
+		{name: "last attempt", wrongGuesses: 2, wantErr: "too many OTP attempts"},
+		{name: "already verified", verified: true, useOTP: true, wantErr: "already verified"},
+		{name: "inactive user", inactive: true, useOTP: true, wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, "correct horse battery staple")
+			user.IsActive = !tt.inactive
+			if tt.verified {
+				user.PhoneVerifiedAt = &verifiedAt
+			}
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+			ctx := context.Background()
+
+			otp, err := a.otp.GenerateAndStoreOTP(ctx, model.OTPPurposePhoneVerification, user.Phone)
+			if err != nil {
+				t.Fatalf("GenerateAndStoreOTP() error = %v", err)
+			}
+			for i := 0; i < tt.wrongGuesses; i++ {
+				_, _ = a.service.ConfirmPhoneVerification(ctx, user.ID.String(), &dto.ConfirmPhoneVerificationRequest{OTP: "000000"})
+			}
+
+			code := "000000"
+			if tt.useOTP {
+				code = otp
+			}
+			_, err = a.service.ConfirmPhoneVerification(ctx, user.ID.String(), &dto.ConfirmPhoneVerificationRequest{OTP: code})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ConfirmPhoneVerification() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ConfirmPhoneVerification() error = %v, want %q", err, tt.wantErr)
+			}
+
+			stored := a.users.user(user.ID)
+			if confirmed := stored.PhoneVerifiedAt != nil && !tt.verified; confirmed != tt.wantConfirmed {
+				t.Errorf("phone verified = %v, want %v", confirmed, tt.wantConfirmed)
+			}
+		})
+	}
+}
+
+func TestAuthServiceSendPhoneVerification(t *testing.T) {
+	user := testUser(t, "correct horse battery staple")
+	a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+	ctx := context.Background()
+
+	resp, err := a.service.SendPhoneVerification(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("SendPhoneVerification() error = %v", err)
+	}
+	if resp.Phone != "*********3210" || resp.ExpiresInSeconds != 600 || resp.CooldownSeconds != 60 {
+		t.Errorf("SendPhoneVerification() = %+v", resp)
+	}
+	if texts := a.sms.sent[user.Phone]; len(texts) != 1 {
+		t.Fatalf("%d texts sent, want 1", len(texts))
+	}
+
+	// A second text within the cooldown is refused
+	var resendErr *OTPResendError
+	if _, err := a.service.SendPhoneVerification(ctx, user.ID.String()); !errors.As(err, &resendErr) {
+		t.Errorf("SendPhoneVerification() during the cooldown error = %v, want an *OTPResendError", err)
+	}
+	if texts := a.sms.sent[user.Phone]; len(texts) != 1 {
+		t.Errorf("%d texts sent, want the refused one not sent", len(texts))
+	}
+}
+
+func TestAuthServiceResetPassword(t *testing.T) {
+	const oldPassword = "correct horse battery staple"
+
+	tests := []struct {
+		name        string
+		newPassword string
+		wrongOTP    bool
+		unknown     bool // Email without an account
+		wantErr     string
+	}{
+		{name: "reset", newPassword: "purple monkey dishwasher lamp"},
+		{name: "wrong code", newPassword: "purple monkey dishwasher lamp", wrongOTP: true, wantErr: "invalid OTP"},
+		{name: "unknown email", newPassword: "purple monkey dishwasher lamp", unknown: true, wantErr: "invalid OTP"},
+		{name: "current password", newPassword: oldPassword, wantErr: "used recently"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, oldPassword)
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+			session := a.login(t, user, oldPassword, "", "")
+			ctx := context.Background()
+
+			email := user.Email
+			if tt.unknown {
+				email = "nobody@example.com"
+			}
+			if _, err := a.service.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: email}); err != nil {
+				t.Fatalf("ForgotPassword() error = %v", err)
+			}
+			otp, sent := a.emails.resetOTPs[email]
+			if sent == tt.unknown {
+				t.Fatalf("reset email sent = %v, want %v", sent, !tt.unknown)
+			}
+			if tt.unknown {
+				otp, _ = a.otp.GenerateOTP(ctx, 8)
+			}
+			if tt.wrongOTP {
+				otp = "00000000"
+			}
+
+			_, err := a.service.ResetPassword(ctx, &dto.ResetPasswordRequest{Email: email, OTP: otp, NewPassword: tt.newPassword})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResetPassword() error = %v, want %q", err, tt.wantErr)
+				}
+				if a.users.user(user.ID).PasswordHash != user.PasswordHash {
+					t.Error("the password changed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResetPassword() error = %v", err)
+			}
+
+			if !a.security.VerifyPassword(ctx, a.users.user(user.ID).PasswordHash, tt.newPassword) {
+				t.Error("the new password isn't set")
+			}
+			if _, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err == nil {
+				t.Error("RefreshToken() with a session from before the reset succeeded")
+			}
+			if _, err := a.security.ValidateJWT(ctx, session.AccessToken); err == nil {
+				t.Error("ValidateJWT() of an access token from before the reset succeeded")
+			}
+			if len(a.emails.passwordChange) != 1 {
+				t.Errorf("%d password changed emails queued, want 1", len(a.emails.passwordChange))
+			}
+
+			// The code is used up
+			_, err = a.service.ResetPassword(ctx, &dto.ResetPasswordRequest{Email: email, OTP: otp, NewPassword: "another fine password here"})
+			if err == nil || !strings.Contains(err.Error(), "invalid OTP") {
+				t.Errorf("ResetPassword() with a used code error = %v, want invalid OTP", err)
+			}
+		})
+	}
+}
+
+func TestAuthServiceChangePassword(t *testing.T) {
+	const oldPassword = "correct horse battery staple"
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		wantErr         string
+		wantRevoked     int
+	}{
+		{name: "change", currentPassword: oldPassword, newPassword: "purple monkey dishwasher lamp", wantRevoked: 1},
+		{name: "wrong current password", currentPassword: "not my password", newPassword: "purple monkey dishwasher lamp", wantErr: "invalid current password"},
+		{name: "same password", currentPassword: oldPassword, newPassword: oldPassword, wantErr: "used recently"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, oldPassword)
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+			ctx := context.Background()
+
+			current := a.login(t, user, oldPassword, "", "")
+			other := a.login(t, user, oldPassword, "", "")
+			claims, err := a.security.ValidateJWT(ctx, current.AccessToken)
+			if err != nil {
+				t.Fatalf("ValidateJWT() error = %v", err)
+			}
+			accessTokenID := claims["jti"].(string)
+
+			resp, err := a.service.ChangePassword(ctx, user.ID.String(), accessTokenID, &dto.ChangePasswordRequest{
+				CurrentPassword: tt.currentPassword,
+				NewPassword:     tt.newPassword,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ChangePassword() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+
+			if resp.RevokedSessions != tt.wantRevoked {
+				t.Errorf("RevokedSessions = %d, want %d", resp.RevokedSessions, tt.wantRevoked)
+			}
+			if _, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: other.RefreshToken}); err == nil {
+				t.Error("RefreshToken() of the other session succeeded")
+			}
+			if _, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: current.RefreshToken}); err != nil {
+				t.Errorf("RefreshToken() of the current session error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthServiceLoginRehashesPassword(t *testing.T) {
+	const password = "correct horse battery staple"
+
+	user := testUser(t, password)
+	a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+
+	// The test security service hashes with bcrypt at the minimum cost, so
+	// move it to argon2id to make the stored hash outdated
+	a.security.hasher = newTestPasswordHasher(t, testArgon2Config)
+
+	a.login(t, user, password, "", "")
+
+	stored := a.users.user(user.ID)
+	if !strings.HasPrefix(stored.PasswordHash, "$argon2id$") {
+		t.Fatalf("stored hash = %q, want an argon2id hash", stored.PasswordHash)
+	}
+	if !a.security.VerifyPassword(context.Background(), stored.PasswordHash, password) {
+		t.Error("the rehashed password doesn't verify")
+	}
+}
+
+func TestAuthServicePasswordHistory(t *testing.T) {
+	// The user moves through these, the history remembers the 2 before the current one
+	passwords := []string{
+		"correct horse battery staple",
+		"purple monkey dishwasher lamp",
+		"quiet river stone lantern",
+		"seven orange kites drifting",
+	}
+	current := passwords[len(passwords)-1]
+
+	tests := []struct {
+		name       string
+		password   string
+		wantReused bool
+	}{
+		{name: "current password", password: current, wantReused: true},
+		{name: "previous password", password: passwords[2], wantReused: true},
+		{name: "second previous password", password: passwords[1], wantReused: true},
+		{name: "pruned password", password: passwords[0]},
+		{name: "new password", password: "five tall ladders waiting"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, passwords[0])
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+			ctx := context.Background()
+
+			for i := 1; i < len(passwords); i++ {
+				_, err := a.service.ChangePassword(ctx, user.ID.String(), "", &dto.ChangePasswordRequest{
+					CurrentPassword: passwords[i-1],
+					NewPassword:     passwords[i],
+				})
+				if err != nil {
+					t.Fatalf("ChangePassword() to password %d error = %v", i, err)
+				}
+			}
+			if entries, _ := a.history.ListRecent(ctx, user.ID, 10); len(entries) != 2 {
+				t.Errorf("%d history entries kept, want 2", len(entries))
+			}
+
+			_, err := a.service.ChangePassword(ctx, user.ID.String(), "", &dto.ChangePasswordRequest{
+				CurrentPassword: current,
+				NewPassword:     tt.password,
+			})
+			if reused := err != nil && strings.Contains(err.Error(), "used recently"); reused != tt.wantReused || (err != nil && !reused) {
+				t.Errorf("ChangePassword() error = %v, want reused %v", err, tt.wantReused)
+			}
+		})
+	}
+}
+
+func TestAuthServiceGetUserInfo(t *testing.T) {
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		mutate  func(user *model.User)
+		want    func(user *model.User) dto.UserInfoResponse
+		wantErr string
+	}{
+		{
+			name:   "phone unverified",
+			mutate: func(user *model.User) {},
+			want: func(user *model.User) dto.UserInfoResponse {
+				return dto.UserInfoResponse{Subject: user.ID.String(), Email: user.Email, EmailVerified: true, PhoneNumber: user.Phone}
+			},
+		},
+		{
+			name:   "phone verified",
+			mutate: func(user *model.User) { user.PhoneVerifiedAt = &verifiedAt },
+			want: func(user *model.User) dto.UserInfoResponse {
+				return dto.UserInfoResponse{Subject: user.ID.String(), Email: user.Email, EmailVerified: true, PhoneNumber: user.Phone, PhoneNumberVerified: true}
+			},
+		},
+		{name: "inactive user", mutate: func(user *model.User) { user.IsActive = false }, wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, "correct horse battery staple")
+			tt.mutate(user)
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+
+			got, err := a.service.GetUserInfo(context.Background(), user.ID.String())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("GetUserInfo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUserInfo() error = %v", err)
+			}
+			if want := tt.want(user); *got != want {
+				t.Errorf("GetUserInfo() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestAuthServiceIntrospectToken(t *testing.T) {
+	const password = "correct horse battery staple"
+
+	tests := []struct {
+		name       string
+		token      func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string
+		wantActive bool
+		wantType   string
+		wantErr    bool
+	}{
+		{
+			name: "access token",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				return session.AccessToken
+			},
+			wantActive: true,
+			wantType:   dto.TokenTypeAccess,
+		},
+		{
+			name: "refresh token",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				return session.RefreshToken
+			},
+			wantActive: true,
+			wantType:   dto.TokenTypeRefresh,
+		},
+		{
+			name: "rotated refresh token",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				if _, err := a.service.RefreshToken(context.Background(), &dto.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err != nil {
+					t.Fatalf("RefreshToken() error = %v", err)
+				}
+				return session.RefreshToken
+			},
+		},
+		{
+			name: "logged out everywhere",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				time.Sleep(2 * time.Millisecond) // Cutoffs have millisecond precision
+				if _, err := a.service.LogoutAll(context.Background(), user.ID.String()); err != nil {
+					t.Fatalf("LogoutAll() error = %v", err)
+				}
+				return session.AccessToken
+			},
+		},
+		{
+			name: "deactivated user",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				user.IsActive = false
+				_ = a.users.Update(context.Background(), user)
+				return session.AccessToken
+			},
+		},
+		{
+			name: "garbage",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				return "not.a.token"
+			},
+		},
+		{
+			name: "revocation check unavailable",
+			token: func(t *testing.T, a *testAuth, user *model.User, session *dto.LoginResponse) string {
+				a.redis.client.Close()
+				return session.AccessToken
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, password)
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+			session := a.login(t, user, password, "", "")
+
+			got, err := a.service.IntrospectToken(context.Background(), &dto.IntrospectionRequest{Token: tt.token(t, a, user, session)})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("IntrospectToken() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IntrospectToken() error = %v", err)
+			}
+
+			if got.Active != tt.wantActive || got.TokenType != tt.wantType {
+				t.Errorf("IntrospectToken() = %+v, want active %v of type %q", got, tt.wantActive, tt.wantType)
+			}
+			if got.Active && (got.Subject != user.ID.String() || len(got.Roles) != 1 || got.Roles[0] != "user" || got.Issuer != "test") {
+				t.Errorf("IntrospectToken() = %+v, want the user's claims", got)
+			}
+			if !got.Active && (got.Subject != "" || got.TokenID != "" || got.Roles != nil) {
+				t.Errorf("IntrospectToken() of an inactive token = %+v, want nothing but active=false", got)
+			}
+		})
+	}
+}
+
+func TestAuthServiceSessions(t *testing.T) {
+	const (
+		password = "correct horse battery staple"
+		chrome   = "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36"
+		firefox  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+	)
+
+	user := testUser(t, password)
+	a := newTestAuthService(t, RefreshBindingConfig{Policy: RefreshBindingIgnore}, user)
+	ctx := context.Background()
+
+	phone := a.login(t, user, password, chrome, "203.0.113.7")
+	time.Sleep(2 * time.Millisecond)
+	laptop := a.login(t, user, password, firefox, "198.51.100.4")
+
+	claims, err := a.security.ValidateJWT(ctx, phone.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	listed, err := a.service.ListSessions(ctx, user.ID.String(), claims["jti"].(string))
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+
+	want := []struct {
+		device  string
+		ip      string
+		current bool
+	}{
+		{device: "Firefox on Windows", ip: "198.51.100.4"},
+		{device: "Chrome on Android", ip: "203.0.113.7", current: true},
+	}
+	if len(listed.Sessions) != len(want) {
+		t.Fatalf("ListSessions() listed %d sessions, want %d", len(listed.Sessions), len(want))
+	}
+	for i, session := range listed.Sessions {
+		if session.Device != want[i].device || session.ClientIP != want[i].ip || session.Current != want[i].current {
+			t.Errorf("session %d = %+v, want %+v", i, session, want[i])
+		}
+	}
+
+	tests := []struct {
+		name      string
+		userID    string
+		sessionID string
+		wantErr   string
+	}{
+		{name: "another user's session", userID: uuid.New().String(), sessionID: listed.Sessions[0].ID, wantErr: "not found"},
+		{name: "unknown session", userID: user.ID.String(), sessionID: "no-such-session", wantErr: "not found"},
+		{name: "own session", userID: user.ID.String(), sessionID: listed.Sessions[0].ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.service.RevokeSession(ctx, tt.userID, tt.sessionID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("RevokeSession() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RevokeSession() error = %v", err)
+			}
+		})
+	}
+
+	// The laptop session ended, its tokens with it
+	if _, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: laptop.RefreshToken}); err == nil {
+		t.Error("RefreshToken() of the revoked session succeeded")
+	}
+	if _, err := a.security.ValidateJWT(ctx, laptop.AccessToken); err == nil {
+		t.Error("ValidateJWT() of the revoked session's access token succeeded")
+	}
+	remaining, err := a.service.ListSessions(ctx, user.ID.String(), "")
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(remaining.Sessions) != 1 || remaining.Sessions[0].Device != "Chrome on Android" {
+		t.Errorf("ListSessions() after the revocation = %+v, want the phone only", remaining.Sessions)
+	}
+}
+
+func TestAuthServiceRefreshBinding(t *testing.T) {
+	const (
+		password = "correct horse battery staple"
+		chrome   = "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36"
+		firefox  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+	)
+
+	tests := []struct {
+		name      string
+		policy    string
+		userAgent string
+		clientIP  string
+		wantErr   bool
+	}{
+		{name: "same client", policy: RefreshBindingReject, userAgent: chrome, clientIP: "203.0.113.7"},
+		{name: "same subnet", policy: RefreshBindingReject, userAgent: chrome, clientIP: "203.0.113.200"},
+		{name: "other subnet rejected", policy: RefreshBindingReject, userAgent: chrome, clientIP: "198.51.100.4", wantErr: true},
+		{name: "other browser rejected", policy: RefreshBindingReject, userAgent: firefox, clientIP: "203.0.113.7", wantErr: true},
+		{name: "other browser logged", policy: RefreshBindingLog, userAgent: firefox, clientIP: "198.51.100.4"},
+		{name: "other browser ignored", policy: RefreshBindingIgnore, userAgent: firefox, clientIP: "198.51.100.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t, password)
+			a := newTestAuthService(t, RefreshBindingConfig{Policy: tt.policy, IPv4Prefix: 24, IPv6Prefix: 64}, user)
+			session := a.login(t, user, password, chrome, "203.0.113.7")
+
+			ctx := context.WithValue(context.Background(), "client_ip", tt.clientIP)
+			ctx = context.WithValue(ctx, "user_agent", tt.userAgent)
+			_, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: session.RefreshToken})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RefreshToken() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+
+			// The rightful owner can still refresh
+			ctx = context.WithValue(context.Background(), "client_ip", "203.0.113.7")
+			ctx = context.WithValue(ctx, "user_agent", chrome)
+			if _, err := a.service.RefreshToken(ctx, &dto.RefreshTokenRequest{RefreshToken: session.RefreshToken}); err != nil {
+				t.Errorf("RefreshToken() from the issuing client error = %v", err)
+			}
+		})
+	}
+}
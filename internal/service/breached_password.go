@@ -0,0 +1,123 @@
+// internal/service/breached_password.go
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// Passwords are looked up by k-anonymity: the SHA-1 of the password is split
+// into a 5 hex character prefix, which selects a range, and the remaining 35
+// characters, which are searched for in that range. A range-API client only
+// ever sends the prefix.
+const breachedPasswordPrefixLength = 5
+
+// Implementation of the BreachedPasswordChecker interface
+type breachedPasswordChecker struct {
+	source   PasswordRangeSource
+	minCount int
+	logger   *logger.Logger
+}
+
+// NewBreachedPasswordChecker creates a checker that rejects passwords seen at
+// least minCount times in the ranges served by source
+func NewBreachedPasswordChecker(source PasswordRangeSource, minCount int, logger *logger.Logger) BreachedPasswordChecker {
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &breachedPasswordChecker{
+		source:   source,
+		minCount: minCount,
+		logger:   logger,
+	}
+}
+
+// IsBreached reports whether the password appears in the corpus
+func (c *breachedPasswordChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.source.Range(ctx, digest[:breachedPasswordPrefixLength])
+	if err != nil {
+		c.logger.Error("Breached password lookup failed", c.logger.Field("error", err.Error()))
+		return false, err
+	}
+
+	return suffixes[digest[breachedPasswordPrefixLength:]] >= c.minCount, nil
+}
+
+// filePasswordRangeSource serves ranges from a local copy of the corpus in
+// the HIBP downloader layout: one <PREFIX>.txt file per prefix with
+// SUFFIX:COUNT lines
+type filePasswordRangeSource struct {
+	dir string
+}
+
+// NewFilePasswordRangeSource opens a local prefix-partitioned corpus and
+// returns how many range files it holds. Ranges are read on demand so even
+// the full corpus doesn't have to fit in memory.
+func NewFilePasswordRangeSource(dir string) (PasswordRangeSource, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+
+	ranges := 0
+	for _, entry := range entries {
+		if !entry.IsDir() && isRangeFileName(entry.Name()) {
+			ranges++
+		}
+	}
+	if ranges == 0 {
+		return nil, 0, fmt.Errorf("breached password corpus %s holds no <PREFIX>.txt range files", dir)
+	}
+
+	return &filePasswordRangeSource{dir: dir}, ranges, nil
+}
+
+// Range returns the suffixes of a prefix with their breach counts, a prefix
+// without a file has no breached passwords
+func (s *filePasswordRangeSource) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	f, err := os.Open(filepath.Join(s.dir, strings.ToUpper(prefix)+".txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]int{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			continue
+		}
+		suffixes[strings.ToUpper(suffix)] = n
+	}
+
+	return suffixes, scanner.Err()
+}
+
+func isRangeFileName(name string) bool {
+	prefix, ok := strings.CutSuffix(name, ".txt")
+	if !ok || len(prefix) != breachedPasswordPrefixLength {
+		return false
+	}
+	_, err := hex.DecodeString(prefix + "0")
+	return err == nil
+}
@@ -0,0 +1,153 @@
+// internal/service/breached_password_test.go
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// passwordDigest returns the range prefix and suffix of a password
+func passwordDigest(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return digest[:breachedPasswordPrefixLength], digest[breachedPasswordPrefixLength:]
+}
+
+// writeRangeFile writes a range file of the corpus in dir
+func writeRangeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestFilePasswordRangeSourceRange(t *testing.T) {
+	dir := t.TempDir()
+	prefix, suffix := passwordDigest("password1")
+	writeRangeFile(t, dir, prefix+".txt", strings.Join([]string{
+		suffix + ":42",
+		"  " + strings.ToLower("0123456789ABCDEF0123456789ABCDEF012") + ":7\r",
+		"not a line",
+		"0123456789ABCDEF0123456789ABCDEF013:lots",
+		"",
+	}, "\n"))
+	writeRangeFile(t, dir, "README.md", "not a range")
+
+	source, ranges, err := NewFilePasswordRangeSource(dir)
+	if err != nil {
+		t.Fatalf("NewFilePasswordRangeSource() error = %v", err)
+	}
+	if ranges != 1 {
+		t.Errorf("NewFilePasswordRangeSource() found %d ranges, want 1", ranges)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   map[string]int
+	}{
+		{
+			name:   "range file",
+			prefix: prefix,
+			want:   map[string]int{suffix: 42, "0123456789ABCDEF0123456789ABCDEF012": 7},
+		},
+		{name: "lowercase prefix", prefix: strings.ToLower(prefix), want: map[string]int{suffix: 42, "0123456789ABCDEF0123456789ABCDEF012": 7}},
+		{name: "prefix without a file", prefix: "FFFFF", want: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := source.Range(context.Background(), tt.prefix)
+			if err != nil {
+				t.Fatalf("Range() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Range() = %v, want %v", got, tt.want)
+			}
+			for suffix, count := range tt.want {
+				if got[suffix] != count {
+					t.Errorf("Range()[%s] = %d, want %d", suffix, got[suffix], count)
+				}
+			}
+		})
+	}
+}
+
+func TestNewFilePasswordRangeSourceErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{name: "no range files", files: []string{"README.md", "ABCDE.csv", "ABCD.txt", "GHIJK.txt"}},
+		{name: "empty directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				writeRangeFile(t, dir, name, "")
+			}
+			if _, _, err := NewFilePasswordRangeSource(dir); err == nil {
+				t.Error("NewFilePasswordRangeSource() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, _, err := NewFilePasswordRangeSource(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("NewFilePasswordRangeSource() of a missing directory error = nil, want an error")
+	}
+}
+
+// stubRangeSource serves fixed ranges
+type stubRangeSource struct {
+	ranges map[string]map[string]int
+	err    error
+}
+
+func (s *stubRangeSource) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	return s.ranges[prefix], s.err
+}
+
+func TestBreachedPasswordChecker(t *testing.T) {
+	prefix, suffix := passwordDigest("password1")
+	source := &stubRangeSource{ranges: map[string]map[string]int{prefix: {suffix: 3}}}
+	lookupErr := errors.New("range API unavailable")
+
+	tests := []struct {
+		name     string
+		source   PasswordRangeSource
+		minCount int
+		password string
+		want     bool
+		wantErr  error
+	}{
+		{name: "breached", source: source, minCount: 1, password: "password1", want: true},
+		{name: "seen as often as required", source: source, minCount: 3, password: "password1", want: true},
+		{name: "seen too rarely", source: source, minCount: 4, password: "password1"},
+		{name: "minimum count defaults to 1", source: source, minCount: 0, password: "password1", want: true},
+		{name: "not breached", source: source, minCount: 1, password: "a much better passphrase"},
+		{name: "lookup fails", source: &stubRangeSource{err: lookupErr}, minCount: 1, password: "password1", wantErr: lookupErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewBreachedPasswordChecker(tt.source, tt.minCount, &logger.Logger{Logger: zap.NewNop()})
+			got, err := checker.IsBreached(context.Background(), tt.password)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("IsBreached() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
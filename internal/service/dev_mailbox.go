@@ -0,0 +1,210 @@
+// internal/service/dev_mailbox.go
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// devMailboxFile is the JSON-lines file captured mail is appended to
+const devMailboxFile = "mailbox.jsonl"
+
+// CapturedEmail is an email captured by the development mailbox
+type CapturedEmail struct {
+	ID         string                 `json:"id"`
+	To         []string               `json:"to"`
+	Subject    string                 `json:"subject"`
+	Template   string                 `json:"template,omitempty"`
+	Locale     string                 `json:"locale,omitempty"`
+	OTP        string                 `json:"otp,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	TextBody   string                 `json:"text_body"`
+	HTMLBody   string                 `json:"html_body,omitempty"`
+	CapturedAt time.Time              `json:"captured_at"`
+}
+
+// DevMailboxConfig holds development mailbox configuration
+type DevMailboxConfig struct {
+	MaxMessages int    // Messages kept in memory, oldest are dropped first
+	Dir         string // Optional directory where messages are also appended to a file
+}
+
+// DevMailbox is an EmailSender for development that keeps every message so
+// it can be inspected over HTTP instead of being delivered (or dropped)
+type DevMailbox struct {
+	config DevMailboxConfig
+	next   EmailSender // Optional real sender the message is forwarded to
+
+	mu       sync.RWMutex
+	messages []CapturedEmail
+}
+
+// NewDevMailbox creates a development mailbox. When next is not nil captured
+// messages are also forwarded to it, e.g. to a local SMTP stand-in.
+func NewDevMailbox(config DevMailboxConfig, next EmailSender) (*DevMailbox, error) {
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = 200
+	}
+
+	mailbox := &DevMailbox{
+		config: config,
+		next:   next,
+	}
+
+	if config.Dir != "" {
+		if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create mailbox directory: %w", err)
+		}
+		if err := mailbox.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return mailbox, nil
+}
+
+// Send captures the message and forwards it to the next sender if any
+func (m *DevMailbox) Send(ctx context.Context, msg *EmailMessage) error {
+	captured := CapturedEmail{
+		ID:         uuid.New().String(),
+		To:         msg.To,
+		Subject:    msg.Subject,
+		Template:   msg.Template,
+		Locale:     msg.Locale,
+		Data:       msg.Data,
+		TextBody:   msg.TextBody,
+		HTMLBody:   msg.HTMLBody,
+		CapturedAt: time.Now().UTC(),
+	}
+	if otp, ok := msg.Data["OTP"].(string); ok {
+		captured.OTP = otp
+	}
+
+	m.mu.Lock()
+	m.append(captured)
+	m.mu.Unlock()
+
+	if m.config.Dir != "" {
+		if err := m.persist(captured); err != nil {
+			return &EmailDeliveryError{Stage: "capture", Temporary: true, Err: err}
+		}
+	}
+
+	if m.next != nil {
+		return m.next.Send(ctx, msg)
+	}
+
+	return nil
+}
+
+// Messages returns captured messages, newest first, optionally only those sent to recipient
+func (m *DevMailbox) Messages(recipient string) []CapturedEmail {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := make([]CapturedEmail, 0, len(m.messages))
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if recipient == "" || sentTo(m.messages[i], recipient) {
+			messages = append(messages, m.messages[i])
+		}
+	}
+	return messages
+}
+
+// LatestOTP returns the most recent message carrying an OTP for recipient
+func (m *DevMailbox) LatestOTP(recipient string) (*CapturedEmail, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		msg := m.messages[i]
+		if msg.OTP != "" && sentTo(msg, recipient) {
+			return &msg, true
+		}
+	}
+	return nil, false
+}
+
+// Clear removes all captured messages, including the file copy
+func (m *DevMailbox) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+	if m.config.Dir != "" {
+		err := os.Remove(filepath.Join(m.config.Dir, devMailboxFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// append adds a message, dropping the oldest beyond MaxMessages. Caller holds the lock.
+func (m *DevMailbox) append(msg CapturedEmail) {
+	m.messages = append(m.messages, msg)
+	if overflow := len(m.messages) - m.config.MaxMessages; overflow > 0 {
+		m.messages = append([]CapturedEmail(nil), m.messages[overflow:]...)
+	}
+}
+
+func (m *DevMailbox) persist(msg CapturedEmail) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(filepath.Join(m.config.Dir, devMailboxFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// load restores previously captured messages so they survive restarts
+func (m *DevMailbox) load() error {
+	f, err := os.Open(filepath.Join(m.config.Dir, devMailboxFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg CapturedEmail
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue // Skip lines from a partial write
+		}
+		m.append(msg)
+	}
+
+	return scanner.Err()
+}
+
+func sentTo(msg CapturedEmail, recipient string) bool {
+	for _, to := range msg.To {
+		if strings.EqualFold(to, recipient) {
+			return true
+		}
+	}
+	return false
+}
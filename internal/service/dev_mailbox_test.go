@@ -0,0 +1,157 @@
+// internal/service/dev_mailbox_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testMessage returns a message to recipient, carrying otp when it isn't empty
+func testMessage(recipient, subject, otp string) *EmailMessage {
+	msg := &EmailMessage{
+		To:       []string{recipient},
+		Subject:  subject,
+		TextBody: subject,
+		Template: EmailTemplatePasswordChanged,
+		Data:     map[string]interface{}{},
+	}
+	if otp != "" {
+		msg.Template = EmailTemplateVerification
+		msg.Data["OTP"] = otp
+	}
+	return msg
+}
+
+func TestDevMailboxCapture(t *testing.T) {
+	mailbox, err := NewDevMailbox(DevMailboxConfig{}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+	ctx := context.Background()
+
+	sends := []*EmailMessage{
+		testMessage("amal@example.com", "first code", "111111"),
+		testMessage("other@example.com", "other code", "999999"),
+		testMessage("amal@example.com", "second code", "222222"),
+		testMessage("amal@example.com", "password changed", ""),
+	}
+	for _, msg := range sends {
+		if err := mailbox.Send(ctx, msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		recipient string
+		wantOTP   string // Empty if none is expected
+		wantCount int    // Messages listed for the recipient
+	}{
+		{name: "latest of several codes", recipient: "amal@example.com", wantOTP: "222222", wantCount: 3},
+		{name: "recipient matches case-insensitively", recipient: "AMAL@example.com", wantOTP: "222222", wantCount: 3},
+		{name: "other recipient", recipient: "other@example.com", wantOTP: "999999", wantCount: 1},
+		{name: "nothing captured", recipient: "nobody@example.com"},
+		{name: "every recipient", recipient: "", wantCount: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.recipient != "" {
+				msg, ok := mailbox.LatestOTP(tt.recipient)
+				if ok != (tt.wantOTP != "") || (ok && msg.OTP != tt.wantOTP) {
+					t.Errorf("LatestOTP() = %+v, %v, want %q", msg, ok, tt.wantOTP)
+				}
+			}
+			if messages := mailbox.Messages(tt.recipient); len(messages) != tt.wantCount {
+				t.Errorf("Messages() listed %d messages, want %d", len(messages), tt.wantCount)
+			}
+		})
+	}
+
+	if messages := mailbox.Messages(""); messages[0].Subject != "password changed" {
+		t.Errorf("Messages() starts with %q, want the newest message first", messages[0].Subject)
+	}
+}
+
+func TestDevMailboxDropsOldestMessages(t *testing.T) {
+	mailbox, err := NewDevMailbox(DevMailboxConfig{MaxMessages: 2}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := mailbox.Send(context.Background(), testMessage("amal@example.com", fmt.Sprintf("code %d", i), "111111")); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	messages := mailbox.Messages("")
+	if len(messages) != 2 || messages[0].Subject != "code 3" || messages[1].Subject != "code 2" {
+		t.Errorf("Messages() = %+v, want codes 3 and 2", messages)
+	}
+}
+
+func TestDevMailboxPersists(t *testing.T) {
+	dir := t.TempDir()
+
+	mailbox, err := NewDevMailbox(DevMailboxConfig{Dir: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+	if err := mailbox.Send(context.Background(), testMessage("amal@example.com", "code", "123456")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	// A restarted service finds the message again
+	restarted, err := NewDevMailbox(DevMailboxConfig{Dir: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+	if msg, ok := restarted.LatestOTP("amal@example.com"); !ok || msg.OTP != "123456" {
+		t.Errorf("LatestOTP() after a restart = %+v, %v, want 123456", msg, ok)
+	}
+
+	if err := restarted.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	cleared, err := NewDevMailbox(DevMailboxConfig{Dir: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewDevMailbox() error = %v", err)
+	}
+	if messages := cleared.Messages(""); len(messages) != 0 {
+		t.Errorf("Messages() after Clear() = %d messages, want none", len(messages))
+	}
+}
+
+func TestDevMailboxForwards(t *testing.T) {
+	tests := []struct {
+		name    string
+		nextErr error
+	}{
+		{name: "delivered"},
+		{name: "delivery fails", nextErr: &EmailDeliveryError{Stage: "connect", Temporary: true, Err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeEmailSender{err: tt.nextErr}
+			mailbox, err := NewDevMailbox(DevMailboxConfig{}, next)
+			if err != nil {
+				t.Fatalf("NewDevMailbox() error = %v", err)
+			}
+
+			err = mailbox.Send(context.Background(), testMessage("amal@example.com", "code", "123456"))
+			if !errors.Is(err, tt.nextErr) {
+				t.Errorf("Send() error = %v, want %v", err, tt.nextErr)
+			}
+			if len(next.sent) != 1 {
+				t.Errorf("next sender got %d messages, want 1", len(next.sent))
+			}
+			if _, ok := mailbox.LatestOTP("amal@example.com"); !ok {
+				t.Error("the message wasn't captured")
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// internal/service/email_dispatcher.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// EmailDispatcherConfig holds email outbox dispatcher configuration
+type EmailDispatcherConfig struct {
+	PollInterval time.Duration // How often to look for due messages
+	BatchSize    int           // Messages claimed per poll
+	MaxAttempts  int           // Attempts before a message is dead-lettered
+	BaseBackoff  time.Duration // Delay after the first failure, doubled every attempt
+	MaxBackoff   time.Duration // Upper bound for the retry delay
+	SendTimeout  time.Duration // Timeout for a single delivery attempt
+
+	PayloadCipher *OutboxPayloadCipher // Decrypts the queued template data
+}
+
+// EmailDispatcher delivers queued outbox emails in the background, retrying
+// temporary failures with exponential backoff
+type EmailDispatcher struct {
+	config         EmailDispatcherConfig
+	outboxRepo     repository.EmailOutboxRepository
+	emailService   EmailService
+	metricsService MetricsService
+	logger         *logger.Logger
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewEmailDispatcher creates a new email outbox dispatcher
+func NewEmailDispatcher(
+	config EmailDispatcherConfig,
+	outboxRepo repository.EmailOutboxRepository,
+	emailService EmailService,
+	metricsService MetricsService,
+	logger *logger.Logger,
+) *EmailDispatcher {
+	// Use default values if not provided
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 20
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = 30 * time.Second
+	}
+	if config.MaxBackoff < config.BaseBackoff {
+		config.MaxBackoff = config.BaseBackoff
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = 30 * time.Second
+	}
+
+	return &EmailDispatcher{
+		config:         config,
+		outboxRepo:     outboxRepo,
+		emailService:   emailService,
+		metricsService: metricsService,
+		logger:         logger,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start runs the dispatch loop in a goroutine until Stop is called or ctx ends
+func (d *EmailDispatcher) Start(ctx context.Context) {
+	go d.run(ctx)
+}
+
+// Stop signals the dispatch loop to exit and waits for the message being
+// delivered, claimed messages that weren't tried yet become due again after
+// their lease
+func (d *EmailDispatcher) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+	<-d.done
+}
+
+// stopping reports whether Stop was called
+func (d *EmailDispatcher) stopping() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *EmailDispatcher) run(ctx context.Context) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	d.logger.Info("Email dispatcher started",
+		d.logger.Field("poll_interval", d.config.PollInterval.String()),
+		d.logger.Field("max_attempts", d.config.MaxAttempts))
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			claimed, err := d.dispatchBatch(ctx)
+			if err != nil {
+				d.logger.Error("Failed to dispatch email batch", d.logger.Field("error", err.Error()))
+				break
+			}
+			if claimed < d.config.BatchSize || d.stopping() {
+				break
+			}
+		}
+
+		if pending, err := d.outboxRepo.CountByStatus(ctx, model.EmailOutboxStatusPending); err == nil {
+			d.metricsService.EmailOutboxBacklog(ctx, pending)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.stop:
+			d.logger.Info("Email dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchBatch claims and delivers one batch, returning the number claimed
+func (d *EmailDispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	// The lease must outlive a send attempt, or another dispatcher could
+	// pick the message up again while it is still being delivered
+	lease := 2 * d.config.SendTimeout
+	messages, err := d.outboxRepo.ClaimDue(ctx, d.config.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		// Leave the rest to the next dispatcher once their lease expires
+		if d.stopping() {
+			break
+		}
+		d.deliver(ctx, &messages[i])
+	}
+
+	return len(messages), nil
+}
+
+func (d *EmailDispatcher) deliver(ctx context.Context, msg *model.EmailOutbox) {
+	attempts := msg.Attempts + 1
+	start := time.Now()
+
+	err := d.send(ctx, msg)
+	d.metricsService.EmailDeliveryDuration(ctx, time.Since(start).Seconds())
+
+	if err == nil {
+		if err := d.outboxRepo.MarkSent(ctx, msg.ID, attempts); err != nil {
+			// The mail went out, at worst it is sent twice after the lease expires
+			d.logger.Error("Failed to mark outbox email as sent",
+				d.logger.Field("outbox_id", msg.ID.String()),
+				d.logger.Field("error", err.Error()))
+		}
+		d.metricsService.IncEmailSent(ctx, msg.Template)
+		return
+	}
+
+	// Only temporary delivery errors are worth retrying, anything else
+	// (rejected recipient, broken template, ...) fails the same way again
+	reason := "permanent_error"
+	if IsTemporaryEmailError(err) {
+		reason = "temporary_error"
+		if attempts < d.config.MaxAttempts {
+			nextAttempt := time.Now().Add(d.backoff(attempts))
+			if markErr := d.outboxRepo.MarkRetry(ctx, msg.ID, attempts, nextAttempt, err.Error()); markErr != nil {
+				d.logger.Error("Failed to schedule outbox email retry",
+					d.logger.Field("outbox_id", msg.ID.String()),
+					d.logger.Field("error", markErr.Error()))
+			}
+			d.metricsService.IncEmailRetry(ctx, msg.Template, reason)
+			d.logger.Warn("Email delivery failed, will retry",
+				d.logger.Field("outbox_id", msg.ID.String()),
+				d.logger.Field("template", msg.Template),
+				d.logger.Field("attempts", attempts),
+				d.logger.Field("next_attempt_at", nextAttempt.Format(time.RFC3339)),
+				d.logger.Field("error", err.Error()))
+			return
+		}
+		reason = "max_attempts"
+	}
+
+	if markErr := d.outboxRepo.MarkDead(ctx, msg.ID, attempts, err.Error()); markErr != nil {
+		d.logger.Error("Failed to dead-letter outbox email",
+			d.logger.Field("outbox_id", msg.ID.String()),
+			d.logger.Field("error", markErr.Error()))
+	}
+	d.metricsService.IncEmailDeadLettered(ctx, msg.Template, reason)
+	d.logger.Error("Email delivery failed permanently",
+		d.logger.Field("outbox_id", msg.ID.String()),
+		d.logger.Field("template", msg.Template),
+		d.logger.Field("attempts", attempts),
+		d.logger.Field("reason", reason),
+		d.logger.Field("error", err.Error()))
+}
+
+func (d *EmailDispatcher) send(ctx context.Context, msg *model.EmailOutbox) error {
+	if d.config.PayloadCipher == nil {
+		return errors.New("outbox payload cipher is not configured")
+	}
+	payload, err := d.config.PayloadCipher.Open(msg.ID, msg.Payload)
+	if err != nil {
+		return errors.New("invalid outbox payload: " + err.Error())
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return errors.New("invalid outbox payload: " + err.Error())
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, d.config.SendTimeout)
+	defer cancel()
+
+	return d.emailService.SendEmail(sendCtx, &EmailData{
+		To:       msg.Recipient,
+		Template: msg.Template,
+		Locale:   msg.Locale,
+		Data:     data,
+	})
+}
+
+// backoff returns the delay before the next attempt: base * 2^(attempts-1),
+// capped at MaxBackoff, with up to 20% jitter to spread retries out
+func (d *EmailDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempts && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
@@ -0,0 +1,354 @@
+// internal/service/email_dispatcher_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// fakeOutboxRepository keeps the outbox in memory. ClaimDue behaves like the
+// Postgres query: due pending messages are leased by pushing their next
+// attempt forward.
+type fakeOutboxRepository struct {
+	mu       sync.Mutex
+	messages []*model.EmailOutbox
+	leases   []time.Duration
+}
+
+func (r *fakeOutboxRepository) Enqueue(ctx context.Context, msg *model.EmailOutbox) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *msg
+	r.messages = append(r.messages, &copied)
+	return nil
+}
+
+func (r *fakeOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leases = append(r.leases, lease)
+
+	now := time.Now()
+	var claimed []model.EmailOutbox
+	for _, msg := range r.messages {
+		if len(claimed) == limit {
+			break
+		}
+		if msg.Status == model.EmailOutboxStatusPending && !msg.NextAttemptAt.After(now) {
+			msg.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *msg)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *fakeOutboxRepository) update(id uuid.UUID, apply func(msg *model.EmailOutbox)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range r.messages {
+		if msg.ID == id {
+			apply(msg)
+			return nil
+		}
+	}
+	return errors.New("message not found")
+}
+
+func (r *fakeOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID, attempts int) error {
+	return r.update(id, func(msg *model.EmailOutbox) {
+		msg.Status = model.EmailOutboxStatusSent
+		msg.Attempts = attempts
+		msg.Payload = "{}"
+	})
+}
+
+func (r *fakeOutboxRepository) MarkRetry(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.update(id, func(msg *model.EmailOutbox) {
+		msg.Attempts = attempts
+		msg.NextAttemptAt = nextAttemptAt
+		msg.LastError = lastError
+	})
+}
+
+func (r *fakeOutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	return r.update(id, func(msg *model.EmailOutbox) {
+		msg.Status = model.EmailOutboxStatusDead
+		msg.Attempts = attempts
+		msg.Payload = "{}"
+		msg.LastError = lastError
+	})
+}
+
+func (r *fakeOutboxRepository) CountByStatus(ctx context.Context, status string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, msg := range r.messages {
+		if msg.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeEmailSender records the messages it is handed and fails with err
+type fakeEmailSender struct {
+	mu   sync.Mutex
+	sent []*EmailMessage
+	err  error
+	hook func() // Called on every send when set
+}
+
+func (s *fakeEmailSender) Send(ctx context.Context, msg *EmailMessage) error {
+	if s.hook != nil {
+		s.hook()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return s.err
+}
+
+func testOutboxCipher(t *testing.T) *OutboxPayloadCipher {
+	t.Helper()
+
+	payloadCipher, err := NewOutboxPayloadCipher(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatalf("NewOutboxPayloadCipher() error = %v", err)
+	}
+	return payloadCipher
+}
+
+// newTestEmailOutbox wires an email service and a dispatcher to an in-memory
+// outbox and sender
+func newTestEmailOutbox(t *testing.T, config EmailDispatcherConfig) (EmailService, *EmailDispatcher, *fakeOutboxRepository, *fakeEmailSender) {
+	t.Helper()
+
+	templates, err := NewEmailTemplateRegistry("")
+	if err != nil {
+		t.Fatalf("NewEmailTemplateRegistry() error = %v", err)
+	}
+	payloadCipher := testOutboxCipher(t)
+
+	repo := &fakeOutboxRepository{}
+	sender := &fakeEmailSender{}
+	emailService, err := NewEmailService(EmailConfig{
+		FromEmail:     "noreply@example.com",
+		FromName:      "Qubool Kallyaanam",
+		OTPExpiryMins: 15,
+		PayloadCipher: payloadCipher,
+	}, sender, templates, repo)
+	if err != nil {
+		t.Fatalf("NewEmailService() error = %v", err)
+	}
+
+	config.PayloadCipher = payloadCipher
+	dispatcher := NewEmailDispatcher(config, repo, emailService, NewNoOpMetricsService(), &logger.Logger{Logger: zap.NewNop()})
+	return emailService, dispatcher, repo, sender
+}
+
+func TestEmailDispatcherDeliver(t *testing.T) {
+	const maxAttempts = 3
+	baseBackoff := time.Minute
+
+	tests := []struct {
+		name          string
+		sendErr       error
+		prevAttempts  int
+		wantStatus    string
+		wantAttempts  int
+		wantRetryIn   time.Duration // Minimum delay of the next attempt, 0 if none
+		wantLastError bool
+	}{
+		{
+			name:         "sent",
+			wantStatus:   model.EmailOutboxStatusSent,
+			wantAttempts: 1,
+		},
+		{
+			name:          "temporary failure is retried",
+			sendErr:       &EmailDeliveryError{Stage: "connect", Temporary: true, Err: errors.New("connection refused")},
+			wantStatus:    model.EmailOutboxStatusPending,
+			wantAttempts:  1,
+			wantRetryIn:   baseBackoff,
+			wantLastError: true,
+		},
+		{
+			name:          "retries back off",
+			sendErr:       &EmailDeliveryError{Stage: "data", Code: 451, Temporary: true, Err: errors.New("try again later")},
+			prevAttempts:  1,
+			wantStatus:    model.EmailOutboxStatusPending,
+			wantAttempts:  2,
+			wantRetryIn:   2 * baseBackoff,
+			wantLastError: true,
+		},
+		{
+			name:          "permanent failure is dead-lettered",
+			sendErr:       &EmailDeliveryError{Stage: "rcpt_to", Code: 550, Err: errors.New("no such user")},
+			wantStatus:    model.EmailOutboxStatusDead,
+			wantAttempts:  1,
+			wantLastError: true,
+		},
+		{
+			name:          "last attempt is dead-lettered",
+			sendErr:       &EmailDeliveryError{Stage: "connect", Temporary: true, Err: errors.New("connection refused")},
+			prevAttempts:  maxAttempts - 1,
+			wantStatus:    model.EmailOutboxStatusDead,
+			wantAttempts:  maxAttempts,
+			wantLastError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emailService, dispatcher, repo, sender := newTestEmailOutbox(t, EmailDispatcherConfig{
+				MaxAttempts: maxAttempts,
+				BaseBackoff: baseBackoff,
+				MaxBackoff:  time.Hour,
+			})
+			sender.err = tt.sendErr
+			ctx := context.Background()
+
+			if err := emailService.QueueVerificationEmail(ctx, "user@example.com", "123456"); err != nil {
+				t.Fatalf("QueueVerificationEmail() error = %v", err)
+			}
+			repo.messages[0].Attempts = tt.prevAttempts
+
+			start := time.Now()
+			if claimed, err := dispatcher.dispatchBatch(ctx); err != nil || claimed != 1 {
+				t.Fatalf("dispatchBatch() = %d, %v, want 1 message claimed", claimed, err)
+			}
+
+			msg := repo.messages[0]
+			if msg.Status != tt.wantStatus || msg.Attempts != tt.wantAttempts {
+				t.Errorf("message is %s after %d attempts, want %s after %d", msg.Status, msg.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if (msg.LastError != "") != tt.wantLastError {
+				t.Errorf("last error = %q, want one %v", msg.LastError, tt.wantLastError)
+			}
+			if tt.wantRetryIn > 0 {
+				// Up to 20% jitter on top of the delay
+				retryIn := msg.NextAttemptAt.Sub(start)
+				if retryIn < tt.wantRetryIn || retryIn > tt.wantRetryIn*6/5+time.Second {
+					t.Errorf("next attempt in %v, want %v plus up to 20%%", retryIn, tt.wantRetryIn)
+				}
+			}
+			if msg.Status != model.EmailOutboxStatusPending && msg.Payload != "{}" {
+				t.Errorf("payload = %q, want it cleared once the message is done", msg.Payload)
+			}
+
+			if len(sender.sent) != 1 || !strings.Contains(sender.sent[0].TextBody, "123456") {
+				t.Errorf("sender got %d messages, want the verification email", len(sender.sent))
+			}
+		})
+	}
+}
+
+func TestEmailDispatcherLease(t *testing.T) {
+	emailService, dispatcher, repo, sender := newTestEmailOutbox(t, EmailDispatcherConfig{
+		BatchSize:   2,
+		SendTimeout: 10 * time.Second,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := emailService.QueueVerificationEmail(ctx, "user@example.com", "123456"); err != nil {
+			t.Fatalf("QueueVerificationEmail() error = %v", err)
+		}
+	}
+
+	// A dispatcher that dies mid-send leaves its batch leased
+	claimed, err := repo.ClaimDue(ctx, 2, 2*dispatcher.config.SendTimeout)
+	if err != nil || len(claimed) != 2 {
+		t.Fatalf("ClaimDue() = %d messages, %v, want 2", len(claimed), err)
+	}
+
+	if claimed, err := dispatcher.dispatchBatch(ctx); err != nil || claimed != 1 {
+		t.Fatalf("dispatchBatch() = %d, %v, want only the unleased message", claimed, err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("%d emails sent, want 1", len(sender.sent))
+	}
+	if lease := repo.leases[len(repo.leases)-1]; lease != 2*dispatcher.config.SendTimeout {
+		t.Errorf("lease = %v, want twice the send timeout %v", lease, dispatcher.config.SendTimeout)
+	}
+}
+
+func TestEmailDispatcherBackoff(t *testing.T) {
+	d := NewEmailDispatcher(EmailDispatcherConfig{
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  5 * time.Minute,
+	}, nil, nil, nil, nil)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 5, want: 5 * time.Minute},
+		{attempts: 50, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := d.backoff(tt.attempts); got < tt.want || got > tt.want*6/5 {
+				t.Errorf("backoff(%d) = %v, want %v plus up to 20%%", tt.attempts, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEmailDispatcherStop(t *testing.T) {
+	emailService, dispatcher, repo, sender := newTestEmailOutbox(t, EmailDispatcherConfig{
+		PollInterval: time.Hour,
+		BatchSize:    3,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := emailService.QueueVerificationEmail(ctx, "user@example.com", "123456"); err != nil {
+			t.Fatalf("QueueVerificationEmail() error = %v", err)
+		}
+	}
+
+	// Shut down while the first email is being sent
+	stopped := make(chan struct{})
+	sender.hook = func() {
+		sender.hook = nil
+		go func() {
+			dispatcher.Stop()
+			close(stopped)
+		}()
+		<-dispatcher.stop
+	}
+
+	dispatcher.Start(ctx)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() didn't return")
+	}
+
+	sent, _ := repo.CountByStatus(ctx, model.EmailOutboxStatusSent)
+	if sent != 1 || len(sender.sent) != 1 {
+		t.Errorf("%d emails sent, want only the one in flight", sent)
+	}
+	for _, msg := range repo.messages[1:] {
+		if msg.Status != model.EmailOutboxStatusPending || msg.Attempts != 0 {
+			t.Errorf("untried message is %s after %d attempts, want it pending", msg.Status, msg.Attempts)
+		}
+	}
+}
@@ -1,13 +1,77 @@
 // internal/service/email_service.go
 package service
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// ErrEmailDelivery is matched by every EmailDeliveryError via errors.Is
+var ErrEmailDelivery = errors.New("email delivery failed")
+
+// EmailDeliveryError describes why an email could not be delivered
+type EmailDeliveryError struct {
+	Stage     string // SMTP stage that failed (connect, auth, rcpt_to, ...)
+	Code      int    // SMTP reply code, 0 if the failure wasn't an SMTP reply
+	Temporary bool   // Whether retrying later could succeed
+	Err       error
+}
+
+func (e *EmailDeliveryError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("email delivery failed at %s (code %d): %v", e.Stage, e.Code, e.Err)
+	}
+	return fmt.Sprintf("email delivery failed at %s: %v", e.Stage, e.Err)
+}
+
+func (e *EmailDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrEmailDelivery) match any delivery error
+func (e *EmailDeliveryError) Is(target error) bool {
+	return target == ErrEmailDelivery
+}
+
+// IsTemporaryEmailError reports whether a delivery error may succeed on retry
+func IsTemporaryEmailError(err error) bool {
+	var deliveryErr *EmailDeliveryError
+	if errors.As(err, &deliveryErr) {
+		return deliveryErr.Temporary
+	}
+	return false
+}
 
 // EmailData contains data needed for sending emails
 type EmailData struct {
 	To       string
 	Subject  string
 	Template string
+	Locale   string // Recipient's locale, falls back to i18n.DefaultLocale
+	Data     map[string]interface{}
+}
+
+// EmailMessage is a fully rendered message handed to an EmailSender
+type EmailMessage struct {
+	From     string
+	FromName string
+	To       []string
+	Subject  string
+	TextBody string
+	HTMLBody string // Optional, sent as multipart/alternative alongside TextBody
+
+	// What the message was rendered from, not part of the sent mail
+	Template string
+	Locale   string
 	Data     map[string]interface{}
 }
 
@@ -17,30 +81,168 @@ type EmailConfig struct {
 	FromName      string
 	OTPExpiryMins int
 	IsDevelopment bool
+
+	// PayloadCipher encrypts the template data of queued emails
+	PayloadCipher *OutboxPayloadCipher
 }
 
 // Implementation of the EmailService interface
 type emailService struct {
-	config EmailConfig
+	config     EmailConfig
+	sender     EmailSender
+	templates  *EmailTemplateRegistry
+	outboxRepo repository.EmailOutboxRepository
 }
 
 // NewEmailService creates a new email service instance
-func NewEmailService(config EmailConfig) (EmailService, error) {
+func NewEmailService(
+	config EmailConfig,
+	sender EmailSender,
+	templates *EmailTemplateRegistry,
+	outboxRepo repository.EmailOutboxRepository,
+) (EmailService, error) {
+	if sender == nil {
+		return nil, errors.New("an email sender is required")
+	}
+	if templates == nil {
+		return nil, errors.New("email templates are required")
+	}
+
 	return &emailService{
-		config: config,
+		config:     config,
+		sender:     sender,
+		templates:  templates,
+		outboxRepo: outboxRepo,
 	}, nil
 }
 
 // SendVerificationEmail sends an email with verification OTP
 func (s *emailService) SendVerificationEmail(ctx context.Context, to string, otp string) error {
-	// In development mode, just log the OTP
-	if s.config.IsDevelopment {
-		// Log the OTP instead of sending an email
-		// In a real implementation, you'd use a proper logger
-		return nil
+	return s.SendEmail(ctx, s.verificationEmail(ctx, to, otp))
+}
+
+// QueueVerificationEmail queues an email with verification OTP in the outbox
+func (s *emailService) QueueVerificationEmail(ctx context.Context, to string, otp string) error {
+	return s.QueueEmail(ctx, s.verificationEmail(ctx, to, otp))
+}
+
+func (s *emailService) verificationEmail(ctx context.Context, to string, otp string) *EmailData {
+	return &EmailData{
+		To:       to,
+		Template: EmailTemplateVerification,
+		Locale:   i18n.LocaleFromContext(ctx),
+		Data: map[string]interface{}{
+			"OTP":        otp,
+			"ExpiryMins": s.config.OTPExpiryMins,
+		},
+	}
+}
+
+// QueuePasswordResetEmail queues an email with a password reset OTP in the outbox
+func (s *emailService) QueuePasswordResetEmail(ctx context.Context, to string, otp string, expiryMins int) error {
+	return s.QueueEmail(ctx, &EmailData{
+		To:       to,
+		Template: EmailTemplatePasswordReset,
+		Locale:   i18n.LocaleFromContext(ctx),
+		Data: map[string]interface{}{
+			"OTP":        otp,
+			"ExpiryMins": expiryMins,
+		},
+	})
+}
+
+// QueuePasswordChangedEmail queues the notice that the account password was changed
+func (s *emailService) QueuePasswordChangedEmail(ctx context.Context, to string, changedAt time.Time) error {
+	return s.QueueEmail(ctx, &EmailData{
+		To:       to,
+		Template: EmailTemplatePasswordChanged,
+		Locale:   i18n.LocaleFromContext(ctx),
+		Data: map[string]interface{}{
+			"Time": changedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		},
+	})
+}
+
+// QueueEmail stores the email in the outbox for the dispatcher to deliver.
+// When ctx carries a transaction the email is only queued if it commits.
+func (s *emailService) QueueEmail(ctx context.Context, data *EmailData) error {
+	if s.outboxRepo == nil || s.config.PayloadCipher == nil {
+		return errors.New("email outbox is not configured")
+	}
+
+	// Fail now rather than dead-lettering a message that can never render
+	if !s.templates.Has(data.Template) {
+		return fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, data.Template)
+	}
+
+	payload, err := json.Marshal(data.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email data: %w", err)
+	}
+
+	locale := i18n.Normalize(data.Locale)
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+
+	id := uuid.New()
+	sealed, err := s.config.PayloadCipher.Seal(id, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt email data: %w", err)
+	}
+
+	now := time.Now()
+	return s.outboxRepo.Enqueue(ctx, &model.EmailOutbox{
+		ID:            id,
+		Recipient:     data.To,
+		Template:      data.Template,
+		Locale:        locale,
+		Payload:       sealed,
+		Status:        model.EmailOutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+}
+
+// SendEmail renders the named template and delivers it
+func (s *emailService) SendEmail(ctx context.Context, data *EmailData) error {
+	locale := i18n.Normalize(data.Locale)
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+
+	// Common values every template can rely on
+	templateData := map[string]interface{}{
+		"AppName": s.config.FromName,
+		"Year":    time.Now().Year(),
+		"Locale":  locale,
+		"Dir":     i18n.Direction(locale),
+	}
+	for key, value := range data.Data {
+		templateData[key] = value
+	}
+
+	rendered, err := s.templates.Render(data.Template, templateData)
+	if err != nil {
+		return err
+	}
+
+	// An explicit subject overrides the template's subject line
+	subject := rendered.Subject
+	if data.Subject != "" {
+		subject = data.Subject
 	}
 
-	// TODO: Implement actual email sending
-	// This is typically done using a library like mailgun, sendgrid, etc.
-	return nil
+	return s.sender.Send(ctx, &EmailMessage{
+		From:     s.config.FromEmail,
+		FromName: s.config.FromName,
+		To:       []string{data.To},
+		Subject:  subject,
+		TextBody: rendered.TextBody,
+		HTMLBody: rendered.HTMLBody,
+		Template: data.Template,
+		Locale:   locale,
+		Data:     data.Data,
+	})
 }
@@ -0,0 +1,171 @@
+// internal/service/email_templates.go
+package service
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// Email template names
+const (
+	EmailTemplateVerification    = "verification"
+	EmailTemplatePasswordReset   = "password_reset"
+	EmailTemplateNewDeviceAlert  = "new_device_alert"
+	EmailTemplatePasswordChanged = "password_changed"
+)
+
+// Every template is made of a subject line, a plain-text body and an
+// optional HTML body which is rendered inside the shared layout:
+//
+//	<name>.subject.tmpl, <name>.txt.tmpl, <name>.html.tmpl
+const (
+	emailSubjectSuffix  = ".subject.tmpl"
+	emailTextSuffix     = ".txt.tmpl"
+	emailHTMLSuffix     = ".html.tmpl"
+	emailLayoutTemplate = "layout.html.tmpl"
+)
+
+//go:embed templates/email/*.tmpl
+var embeddedEmailTemplates embed.FS
+
+// ErrEmailTemplateNotFound is returned when rendering an unknown template
+var ErrEmailTemplateNotFound = errors.New("email template not found")
+
+// RenderedEmail holds the output of rendering an email template
+type RenderedEmail struct {
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// emailTemplateFuncs are available to every template. Copy lives in the
+// i18n message catalogs: {{t .Locale "message.id" args...}}
+var emailTemplateFuncs = map[string]interface{}{
+	"t": i18n.Translate,
+}
+
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template // nil when the template has no HTML body
+}
+
+// EmailTemplateRegistry renders the email templates by name
+type EmailTemplateRegistry struct {
+	templates map[string]*emailTemplate
+}
+
+// NewEmailTemplateRegistry parses all email templates. When dir is empty the
+// templates embedded in the binary are used, otherwise they are loaded from
+// dir so copy can be changed without a rebuild.
+func NewEmailTemplateRegistry(dir string) (*EmailTemplateRegistry, error) {
+	var fsys fs.FS
+	if dir != "" {
+		fsys = os.DirFS(dir)
+	} else {
+		sub, err := fs.Sub(embeddedEmailTemplates, "templates/email")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+
+	subjects, err := fs.Glob(fsys, "*"+emailSubjectSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("no email templates found")
+	}
+
+	registry := &EmailTemplateRegistry{
+		templates: make(map[string]*emailTemplate, len(subjects)),
+	}
+
+	for _, subjectFile := range subjects {
+		name := strings.TrimSuffix(subjectFile, emailSubjectSuffix)
+
+		tmpl, err := parseEmailTemplate(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %q: %w", name, err)
+		}
+		registry.templates[name] = tmpl
+	}
+
+	return registry, nil
+}
+
+func parseEmailTemplate(fsys fs.FS, name string) (*emailTemplate, error) {
+	subject, err := texttemplate.New(name+emailSubjectSuffix).Funcs(emailTemplateFuncs).ParseFS(fsys, name+emailSubjectSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := texttemplate.New(name+emailTextSuffix).Funcs(emailTemplateFuncs).ParseFS(fsys, name+emailTextSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &emailTemplate{
+		subject: subject.Option("missingkey=error"),
+		text:    text.Option("missingkey=error"),
+	}
+
+	// The HTML body is optional, text-only mails are perfectly valid
+	if _, err := fs.Stat(fsys, name+emailHTMLSuffix); err == nil {
+		html, err := htmltemplate.New(emailLayoutTemplate).Funcs(emailTemplateFuncs).ParseFS(fsys, emailLayoutTemplate, name+emailHTMLSuffix)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.html = html.Option("missingkey=error")
+	}
+
+	return tmpl, nil
+}
+
+// Has reports whether a template with the given name exists
+func (r *EmailTemplateRegistry) Has(name string) bool {
+	_, ok := r.templates[name]
+	return ok
+}
+
+// Render executes the named template with the given data
+func (r *EmailTemplateRegistry) Render(name string, data map[string]interface{}) (*RenderedEmail, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, name)
+	}
+
+	var subject, text bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render text body: %w", err)
+	}
+
+	rendered := &RenderedEmail{
+		// Subjects must be a single line
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		TextBody: text.String(),
+	}
+
+	if tmpl.html != nil {
+		var html bytes.Buffer
+		if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+			return nil, fmt.Errorf("failed to render HTML body: %w", err)
+		}
+		rendered.HTMLBody = html.String()
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,133 @@
+// internal/service/email_templates_test.go
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testEmailTemplateData is what the email service passes to every template
+func testEmailTemplateData() map[string]interface{} {
+	return map[string]interface{}{
+		"AppName":    "Qubool Kallyaanam",
+		"Year":       2024,
+		"Locale":     "en",
+		"Dir":        "ltr",
+		"OTP":        "123456",
+		"ExpiryMins": 15,
+		"Time":       "2024-01-02 03:04 UTC",
+		"Device":     "Firefox on Linux",
+		"IPAddress":  "203.0.113.7",
+	}
+}
+
+func TestEmailTemplateRegistryRendersEmbeddedTemplates(t *testing.T) {
+	registry, err := NewEmailTemplateRegistry("")
+	if err != nil {
+		t.Fatalf("NewEmailTemplateRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		wantText string // Must appear in both bodies
+	}{
+		{name: "verification", template: EmailTemplateVerification, wantText: "123456"},
+		{name: "password reset", template: EmailTemplatePasswordReset, wantText: "123456"},
+		{name: "new device alert", template: EmailTemplateNewDeviceAlert, wantText: "203.0.113.7"},
+		{name: "password changed", template: EmailTemplatePasswordChanged, wantText: "2024-01-02 03:04 UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !registry.Has(tt.template) {
+				t.Fatalf("Has(%s) = false", tt.template)
+			}
+
+			rendered, err := registry.Render(tt.template, testEmailTemplateData())
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if rendered.Subject == "" || strings.ContainsAny(rendered.Subject, "\r\n") {
+				t.Errorf("subject = %q, want a single non-empty line", rendered.Subject)
+			}
+			if !strings.Contains(rendered.TextBody, tt.wantText) {
+				t.Errorf("text body doesn't contain %q:\n%s", tt.wantText, rendered.TextBody)
+			}
+			if !strings.Contains(rendered.HTMLBody, tt.wantText) || !strings.Contains(rendered.HTMLBody, "<html") {
+				t.Errorf("HTML body isn't the layout around %q:\n%s", tt.wantText, rendered.HTMLBody)
+			}
+		})
+	}
+}
+
+func TestEmailTemplateRegistryDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"layout.html.tmpl":      `{{define "layout"}}<html>{{template "content" .}}</html>{{end}}`,
+		"greeting.subject.tmpl": "Hello\n  {{.Name}}\n",
+		"greeting.txt.tmpl":     "Hello {{.Name}}",
+		"greeting.html.tmpl":    `{{define "content"}}<p>Hello {{.Name}}</p>{{end}}`,
+		"notice.subject.tmpl":   "Notice",
+		"notice.txt.tmpl":       "Plain text only",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	registry, err := NewEmailTemplateRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewEmailTemplateRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		data        map[string]interface{}
+		wantErr     bool
+		wantSubject string
+		wantText    string
+		wantHTML    string
+	}{
+		{
+			name:        "HTML is escaped, text is not",
+			template:    "greeting",
+			data:        map[string]interface{}{"Name": "<b>Amal</b>"},
+			wantSubject: "Hello <b>Amal</b>",
+			wantText:    "Hello <b>Amal</b>",
+			wantHTML:    "<html><p>Hello &lt;b&gt;Amal&lt;/b&gt;</p></html>",
+		},
+		{
+			name:        "text-only template",
+			template:    "notice",
+			wantSubject: "Notice",
+			wantText:    "Plain text only",
+		},
+		{name: "missing data", template: "greeting", data: map[string]interface{}{}, wantErr: true},
+		{name: "unknown template", template: "welcome", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := registry.Render(tt.template, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if rendered.Subject != tt.wantSubject || rendered.TextBody != tt.wantText || rendered.HTMLBody != tt.wantHTML {
+				t.Errorf("Render() = %+v, want subject %q, text %q, HTML %q", rendered, tt.wantSubject, tt.wantText, tt.wantHTML)
+			}
+		})
+	}
+
+	if _, err := registry.Render("welcome", nil); !errors.Is(err, ErrEmailTemplateNotFound) {
+		t.Errorf("Render() of an unknown template error = %v, want %v", err, ErrEmailTemplateNotFound)
+	}
+}
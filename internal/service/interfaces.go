@@ -5,7 +5,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model/dto"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
 )
 
 // AuthService defines the interface for authentication operations
@@ -24,6 +28,46 @@ type AuthService interface {
 
 	// Logout logs out a user
 	Logout(ctx context.Context, req *dto.LogoutRequest) error
+
+	// ResendOTP sends a new verification OTP for a pending registration
+	ResendOTP(ctx context.Context, req *dto.ResendOTPRequest) (*dto.ResendOTPResponse, error)
+
+	// SendPhoneVerification texts an OTP to the user's phone number
+	SendPhoneVerification(ctx context.Context, userID string) (*dto.SendPhoneVerificationResponse, error)
+
+	// ConfirmPhoneVerification marks the user's phone number verified using the texted OTP
+	ConfirmPhoneVerification(ctx context.Context, userID string, req *dto.ConfirmPhoneVerificationRequest) (*dto.ConfirmPhoneVerificationResponse, error)
+
+	// ForgotPassword emails a password reset OTP if the email belongs to an active account
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest) (*dto.ForgotPasswordResponse, error)
+
+	// ResetPassword sets a new password using a reset OTP and revokes the user's refresh tokens
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) (*dto.ResetPasswordResponse, error)
+
+	// ChangePassword changes the password of a logged-in user and revokes their other sessions
+	ChangePassword(ctx context.Context, userID, accessTokenID string, req *dto.ChangePasswordRequest) (*dto.ChangePasswordResponse, error)
+
+	// GetUserInfo returns the OpenID Connect standard claims of a user
+	GetUserInfo(ctx context.Context, userID string) (*dto.UserInfoResponse, error)
+
+	// ListSessions lists the user's sessions, marking the one accessTokenID belongs to
+	ListSessions(ctx context.Context, userID, accessTokenID string) (*dto.ListSessionsResponse, error)
+
+	// RevokeSession signs out one of the user's sessions
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+
+	// LogoutAll signs the user out of every session and invalidates all their access tokens
+	LogoutAll(ctx context.Context, userID string) (*dto.LogoutAllResponse, error)
+
+	// IntrospectToken reports whether an access or refresh token is currently valid (RFC 7662)
+	IntrospectToken(ctx context.Context, req *dto.IntrospectionRequest) (*dto.IntrospectionResponse, error)
+}
+
+// SessionLimiter caps how many sessions a user can have at once
+type SessionLimiter interface {
+	// StoreSession stores the refresh token of a new session, making room for
+	// it in the same step, and returns the sessions it evicted
+	StoreSession(ctx context.Context, tokenID string, data TokenData) ([]TokenData, error)
 }
 
 // internal/service/interfaces.go (update the OTPService interface)
@@ -31,34 +75,62 @@ type AuthService interface {
 type OTPService interface {
 	// GenerateOTP generates a new OTP of specified length
 	GenerateOTP(ctx context.Context, length int) (string, error)
-	// GetOTPExpiryTime returns the configured expiry time for OTPs
-	GetOTPExpiryTime(ctx context.Context) time.Time
-	// GenerateAndStoreOTP generates a new OTP and stores it in Redis
-	GenerateAndStoreOTP(ctx context.Context, key string) (string, error)
-	// VerifyOTP verifies an OTP for a given key
-	VerifyOTP(ctx context.Context, key string, otp string) (bool, error)
+	// GetPolicy returns the policy configured for a purpose
+	GetPolicy(purpose model.OTPPurpose) (OTPPolicy, error)
+	// GetOTPExpiryTime returns when an OTP issued now for the purpose expires
+	GetOTPExpiryTime(ctx context.Context, purpose model.OTPPurpose) (time.Time, error)
+	// GenerateAndStoreOTP generates a new OTP for a purpose and recipient and stores it in Redis
+	GenerateAndStoreOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) (string, error)
+	// VerifyOTP verifies an OTP for a purpose and recipient
+	VerifyOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string) (bool, error)
+	// ReserveResend enforces the resend cooldown and daily cap for a purpose and recipient
+	ReserveResend(ctx context.Context, purpose model.OTPPurpose, recipient string) (*repository.OTPResendStatus, error)
+}
+
+// SMSService defines the interface for SMS delivery providers
+type SMSService interface {
+	// SendSMS sends a text message to a phone number
+	SendSMS(ctx context.Context, to string, message string) error
 }
 
 // EmailService defines the interface for email operations
 type EmailService interface {
 	// SendVerificationEmail sends verification email with OTP
 	SendVerificationEmail(ctx context.Context, to string, otp string) error
-	// Additional methods would be added here (send reset password email, etc.)
+	// SendEmail renders the template named in data and sends it
+	SendEmail(ctx context.Context, data *EmailData) error
+	// QueueVerificationEmail queues the verification email in the outbox
+	QueueVerificationEmail(ctx context.Context, to string, otp string) error
+	// QueuePasswordResetEmail queues the password reset email with its OTP in the outbox
+	QueuePasswordResetEmail(ctx context.Context, to string, otp string, expiryMins int) error
+	// QueuePasswordChangedEmail queues the notice that the account password was changed
+	QueuePasswordChangedEmail(ctx context.Context, to string, changedAt time.Time) error
+	// QueueEmail stores the email in the outbox, within the transaction in ctx if any
+	QueueEmail(ctx context.Context, data *EmailData) error
+}
+
+// EmailSender delivers fully rendered email messages (SMTP, API providers, etc.)
+type EmailSender interface {
+	// Send delivers the message, returning an *EmailDeliveryError on failure
+	Send(ctx context.Context, msg *EmailMessage) error
 }
 
 // SecurityService defines security-related operations
 type SecurityService interface {
 	// SanitizeInput cleans input to prevent XSS
 	SanitizeInput(ctx context.Context, input string) string
-	// ValidatePassword checks if a password meets security requirements
-	ValidatePassword(ctx context.Context, password string) (bool, string)
+	// ValidatePassword checks if a password meets security requirements, userInputs
+	// are the user's own details that make a password easier to guess
+	ValidatePassword(ctx context.Context, password string, userInputs ...string) PasswordCheck
 	// HashPassword hashes a password securely
 	HashPassword(ctx context.Context, password string) (string, error)
 	// VerifyPassword checks if a password matches its hash
 	VerifyPassword(ctx context.Context, hashedPassword, password string) bool
+	// PasswordNeedsRehash reports whether a hash is outdated and should be replaced
+	PasswordNeedsRehash(ctx context.Context, hashedPassword string) bool
 
-	// GenerateJWT generates a JWT token for the authenticated user
-	GenerateJWT(ctx context.Context, userID, role string, lastLogin time.Time) (string, error)
+	// GenerateJWT generates a JWT token for the authenticated user and returns it with its token ID
+	GenerateJWT(ctx context.Context, userID, role string, lastLogin time.Time) (string, string, error)
 
 	// GenerateRefreshToken generates a refresh token for the authenticated user
 	GenerateRefreshToken(ctx context.Context, userID string) (string, string, error)
@@ -70,6 +142,37 @@ type SecurityService interface {
 	ExtractTokenID(ctx context.Context, token string) (string, error)
 }
 
+// PasswordHasher creates and verifies self-describing password hashes
+type PasswordHasher interface {
+	// Hash hashes a password with the current algorithm and parameters
+	Hash(password string) (string, error)
+	// Verify checks a password against a hash of any supported algorithm
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether a hash differs from the current algorithm or parameters
+	NeedsRehash(hash string) bool
+}
+
+// PasswordHistoryService prevents users from reusing recent passwords
+type PasswordHistoryService interface {
+	// IsReused reports whether password is the user's current or a recent previous password
+	IsReused(ctx context.Context, user *model.User, password string) (bool, error)
+	// Record remembers the hash a user is moving away from and prunes older entries
+	Record(ctx context.Context, userID uuid.UUID, previousHash string) error
+}
+
+// BreachedPasswordChecker looks passwords up in a breached-password corpus
+type BreachedPasswordChecker interface {
+	// IsBreached reports whether the password has appeared in a data breach
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// PasswordRangeSource serves one k-anonymity range of a breached-password
+// corpus, either from local files or from a range API
+type PasswordRangeSource interface {
+	// Range returns the SHA-1 suffixes under a 5 character hex prefix with their breach counts
+	Range(ctx context.Context, prefix string) (map[string]int, error)
+}
+
 // MetricsService defines methods for recording metrics
 type MetricsService interface {
 	// Registration metrics
@@ -101,19 +204,39 @@ type MetricsService interface {
 	IncLogoutSuccess(ctx context.Context)
 	IncLogoutFailure(ctx context.Context, reason string)
 	LogoutDuration(ctx context.Context, seconds float64)
+
+	// Email outbox metrics
+	IncEmailSent(ctx context.Context, template string)
+	IncEmailRetry(ctx context.Context, template string, reason string)
+	IncEmailDeadLettered(ctx context.Context, template string, reason string)
+	EmailDeliveryDuration(ctx context.Context, seconds float64)
+	EmailOutboxBacklog(ctx context.Context, pending int64)
 }
 
 // RedisService defines operations for Redis
 type RedisService interface {
 	// Token operations
-	StoreRefreshToken(ctx context.Context, tokenID, token string, data TokenData) error
+	StoreRefreshToken(ctx context.Context, tokenID string, data TokenData) error
+	StoreRefreshTokenWithinLimit(ctx context.Context, tokenID string, data TokenData, limit int, evict bool) ([]TokenData, error)
 	GetRefreshTokenData(ctx context.Context, tokenID string) (*TokenData, error)
 	DeleteRefreshToken(ctx context.Context, tokenID string) error
+	RevokeRefreshToken(ctx context.Context, data TokenData) error
+	DeleteAllRefreshTokens(ctx context.Context, userID string) (int, error)
+	GetUserRefreshTokens(ctx context.Context, userID string) ([]TokenData, error)
+
+	// Token family operations, for detecting replayed refresh tokens
+	ConsumeRefreshToken(ctx context.Context, data TokenData) (bool, error)
+	GetRotatedTokenFamily(ctx context.Context, tokenID string) (string, error)
+	RevokeTokenFamily(ctx context.Context, familyID string) (*TokenData, error)
 
 	// Blacklist operations
 	BlacklistToken(ctx context.Context, tokenID string, expiry time.Duration) error
 	IsTokenBlacklisted(ctx context.Context, tokenID string) (bool, error)
 
+	// Per-user revocation of every token issued before a point in time
+	SetTokensRevokedBefore(ctx context.Context, userID string, cutoff time.Time) error
+	GetTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error)
+
 	// Throttling operations
 	IncrementLoginAttempts(ctx context.Context, ip string) (int64, error)
 	IsLoginThrottled(ctx context.Context, ip string) (bool, error)
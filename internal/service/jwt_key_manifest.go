@@ -0,0 +1,276 @@
+// internal/service/jwt_key_manifest.go
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lifecycle of a key in the manifest. A key is added as next, so every
+// verifier learns it before any token is signed with it, then promoted to
+// active, and finally retired when the following key is promoted. Retired
+// keys keep verifying until the tokens they signed have expired.
+const (
+	JWTKeyStatusNext    = "next"
+	JWTKeyStatusActive  = "active"
+	JWTKeyStatusRetired = "retired"
+)
+
+// JWTKeyEntry describes one key pair in the manifest. Key file paths are
+// relative to the manifest's directory unless absolute.
+type JWTKeyEntry struct {
+	KeyID          string     `json:"kid"`
+	Algorithm      string     `json:"alg"`
+	PrivateKeyFile string     `json:"private_key_file,omitempty"`
+	PublicKeyFile  string     `json:"public_key_file"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	PromotedAt     *time.Time `json:"promoted_at,omitempty"`
+	RetiredAt      *time.Time `json:"retired_at,omitempty"`
+}
+
+// JWTKeyManifest lists the keys of a key ring and where each is in its
+// rotation
+type JWTKeyManifest struct {
+	Keys []JWTKeyEntry `json:"keys"`
+}
+
+// LoadJWTKeyManifest reads a key manifest
+func LoadJWTKeyManifest(path string) (*JWTKeyManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key manifest: %w", err)
+	}
+
+	var manifest JWTKeyManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT key manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+// SaveJWTKeyManifest writes a key manifest, replacing the old file atomically
+// so a server starting at the same time never reads half of it
+func SaveJWTKeyManifest(path string, manifest *JWTKeyManifest) error {
+	if err := manifest.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JWT key manifest: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jwt-keys-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write JWT key manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write JWT key manifest: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write JWT key manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write JWT key manifest: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write JWT key manifest: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that the manifest has exactly one active key and that
+// every key can be loaded
+func (m *JWTKeyManifest) Validate() error {
+	seen := make(map[string]bool)
+	active := 0
+
+	for _, entry := range m.Keys {
+		if entry.KeyID == "" {
+			return fmt.Errorf("JWT key manifest has a key without a kid")
+		}
+		if seen[entry.KeyID] {
+			return fmt.Errorf("duplicate JWT key ID %q in manifest", entry.KeyID)
+		}
+		seen[entry.KeyID] = true
+
+		switch entry.Algorithm {
+		case JWTAlgorithmRS256, JWTAlgorithmES256, JWTAlgorithmEdDSA:
+		default:
+			return fmt.Errorf("JWT key %q has unsupported algorithm %q", entry.KeyID, entry.Algorithm)
+		}
+
+		switch entry.Status {
+		case JWTKeyStatusActive:
+			active++
+			if entry.PrivateKeyFile == "" {
+				return fmt.Errorf("active JWT key %q has no private key file", entry.KeyID)
+			}
+		case JWTKeyStatusNext:
+			if entry.PrivateKeyFile == "" {
+				return fmt.Errorf("JWT key %q has no private key file and can't be promoted", entry.KeyID)
+			}
+		case JWTKeyStatusRetired:
+			if entry.RetiredAt == nil {
+				return fmt.Errorf("retired JWT key %q has no retired_at", entry.KeyID)
+			}
+		default:
+			return fmt.Errorf("JWT key %q has unknown status %q", entry.KeyID, entry.Status)
+		}
+
+		if entry.PublicKeyFile == "" && entry.PrivateKeyFile == "" {
+			return fmt.Errorf("JWT key %q has no key files", entry.KeyID)
+		}
+	}
+
+	if active != 1 {
+		return fmt.Errorf("JWT key manifest must have exactly one active key, found %d", active)
+	}
+	return nil
+}
+
+// Add adds a new key. The first key of a manifest becomes active straight
+// away, any later key is added as next.
+func (m *JWTKeyManifest) Add(entry JWTKeyEntry) error {
+	for _, existing := range m.Keys {
+		if existing.KeyID == entry.KeyID {
+			return fmt.Errorf("JWT key %q already exists", entry.KeyID)
+		}
+	}
+
+	now := time.Now().UTC()
+	entry.CreatedAt = now
+	entry.Status = JWTKeyStatusNext
+	if m.Active() == nil {
+		entry.Status = JWTKeyStatusActive
+		entry.PromotedAt = &now
+	}
+
+	m.Keys = append(m.Keys, entry)
+	return nil
+}
+
+// Promote makes a next key the signing key and retires the current one
+func (m *JWTKeyManifest) Promote(keyID string) error {
+	target := m.find(keyID)
+	if target == nil {
+		return fmt.Errorf("JWT key %q not found", keyID)
+	}
+	if target.Status != JWTKeyStatusNext {
+		return fmt.Errorf("JWT key %q is %s, only a next key can be promoted", keyID, target.Status)
+	}
+
+	now := time.Now().UTC()
+	if current := m.Active(); current != nil {
+		current.Status = JWTKeyStatusRetired
+		current.RetiredAt = &now
+	}
+
+	target.Status = JWTKeyStatusActive
+	target.PromotedAt = &now
+	return nil
+}
+
+// Prune removes retired keys whose last tokens have expired, i.e. keys
+// retired more than maxTokenLifetime ago, and returns them
+func (m *JWTKeyManifest) Prune(maxTokenLifetime time.Duration) []JWTKeyEntry {
+	var kept, removed []JWTKeyEntry
+	for _, entry := range m.Keys {
+		if entry.Status == JWTKeyStatusRetired && !retiredKeyStillVerifies(entry, maxTokenLifetime) {
+			removed = append(removed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	m.Keys = kept
+	return removed
+}
+
+// Active returns the signing key, or nil if there is none
+func (m *JWTKeyManifest) Active() *JWTKeyEntry {
+	for i := range m.Keys {
+		if m.Keys[i].Status == JWTKeyStatusActive {
+			return &m.Keys[i]
+		}
+	}
+	return nil
+}
+
+func (m *JWTKeyManifest) find(keyID string) *JWTKeyEntry {
+	for i := range m.Keys {
+		if m.Keys[i].KeyID == keyID {
+			return &m.Keys[i]
+		}
+	}
+	return nil
+}
+
+// retiredKeyStillVerifies reports whether tokens signed before the key was
+// retired may still be valid
+func retiredKeyStillVerifies(entry JWTKeyEntry, maxTokenLifetime time.Duration) bool {
+	return entry.RetiredAt != nil && time.Since(*entry.RetiredAt) < maxTokenLifetime
+}
+
+// LoadJWTKeyRing builds a key ring from a manifest. The active key signs,
+// next keys and retired keys younger than maxTokenLifetime only verify.
+func LoadJWTKeyRing(manifestPath string, maxTokenLifetime time.Duration) (*JWTKeyRing, error) {
+	manifest, err := LoadJWTKeyManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(manifestPath)
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+
+	var signing *JWTSigningKey
+	var verification []*JWTSigningKey
+
+	for _, entry := range manifest.Keys {
+		if entry.Status == JWTKeyStatusRetired && !retiredKeyStillVerifies(entry, maxTokenLifetime) {
+			continue
+		}
+
+		if entry.Status == JWTKeyStatusActive {
+			signing, err = LoadJWTSigningKey(entry.Algorithm, entry.KeyID, resolve(entry.PrivateKeyFile), resolve(entry.PublicKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("JWT key %q: %w", entry.KeyID, err)
+			}
+			continue
+		}
+
+		var key *JWTSigningKey
+		if entry.PublicKeyFile != "" {
+			key, err = LoadJWTVerificationKey(entry.Algorithm, entry.KeyID, resolve(entry.PublicKeyFile))
+		} else {
+			key, err = LoadJWTSigningKey(entry.Algorithm, entry.KeyID, resolve(entry.PrivateKeyFile), "")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", entry.KeyID, err)
+		}
+
+		// Only the active key may sign
+		key.SignKey = nil
+		verification = append(verification, key)
+	}
+
+	return NewJWTKeyRing(signing, verification...)
+}
@@ -0,0 +1,221 @@
+// internal/service/jwt_key_manifest_test.go
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestES256Key writes a P-256 key pair as <name>.pem and <name>.pub.pem
+// to dir and returns its manifest entry
+func writeTestES256Key(t *testing.T, dir, name string) JWTKeyEntry {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to encode private key: %v", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+
+	entry := JWTKeyEntry{
+		KeyID:          name,
+		Algorithm:      JWTAlgorithmES256,
+		PrivateKeyFile: name + ".pem",
+		PublicKeyFile:  name + ".pub.pem",
+	}
+	files := map[string]*pem.Block{
+		entry.PrivateKeyFile: {Type: "PRIVATE KEY", Bytes: private},
+		entry.PublicKeyFile:  {Type: "PUBLIC KEY", Bytes: public},
+	}
+	for file, block := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	return entry
+}
+
+func TestJWTKeyManifestLifecycle(t *testing.T) {
+	manifest := &JWTKeyManifest{}
+
+	if err := manifest.Add(JWTKeyEntry{KeyID: "k1", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k1.pem"}); err != nil {
+		t.Fatalf("Add(k1) error = %v", err)
+	}
+	if err := manifest.Add(JWTKeyEntry{KeyID: "k2", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k2.pem"}); err != nil {
+		t.Fatalf("Add(k2) error = %v", err)
+	}
+	if err := manifest.Add(JWTKeyEntry{KeyID: "k2", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k2.pem"}); err == nil {
+		t.Error("Add() accepted a duplicate kid")
+	}
+
+	if k1, k2 := manifest.find("k1"), manifest.find("k2"); k1.Status != JWTKeyStatusActive || k2.Status != JWTKeyStatusNext {
+		t.Fatalf("after Add: k1 is %s, k2 is %s, want active and next", k1.Status, k2.Status)
+	}
+
+	tests := []struct {
+		name  string
+		keyID string
+	}{
+		{name: "active key", keyID: "k1"},
+		{name: "unknown key", keyID: "k9"},
+	}
+	for _, tt := range tests {
+		t.Run("promote "+tt.name, func(t *testing.T) {
+			if err := manifest.Promote(tt.keyID); err == nil {
+				t.Errorf("Promote(%s) succeeded, want an error", tt.keyID)
+			}
+		})
+	}
+
+	if err := manifest.Promote("k2"); err != nil {
+		t.Fatalf("Promote(k2) error = %v", err)
+	}
+	k1 := manifest.find("k1")
+	if k1.Status != JWTKeyStatusRetired || k1.RetiredAt == nil {
+		t.Errorf("k1 after promoting k2 = %+v, want retired", k1)
+	}
+	if active := manifest.Active(); active == nil || active.KeyID != "k2" {
+		t.Errorf("Active() = %+v, want k2", active)
+	}
+	if err := manifest.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// k1 may still have signed unexpired tokens
+	if removed := manifest.Prune(time.Hour); len(removed) != 0 {
+		t.Errorf("Prune() removed %v right after the rotation", removed)
+	}
+
+	retiredAt := time.Now().Add(-2 * time.Hour)
+	manifest.find("k1").RetiredAt = &retiredAt
+	removed := manifest.Prune(time.Hour)
+	if len(removed) != 1 || removed[0].KeyID != "k1" || manifest.find("k1") != nil {
+		t.Errorf("Prune() removed %v, want k1", removed)
+	}
+}
+
+func TestJWTKeyManifestValidate(t *testing.T) {
+	retiredAt := time.Now()
+	active := JWTKeyEntry{KeyID: "k1", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k1.pem", Status: JWTKeyStatusActive}
+
+	tests := []struct {
+		name    string
+		keys    []JWTKeyEntry
+		wantErr bool
+	}{
+		{name: "one active key", keys: []JWTKeyEntry{active}},
+		{
+			name: "active, next and retired",
+			keys: []JWTKeyEntry{
+				active,
+				{KeyID: "k2", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k2.pem", Status: JWTKeyStatusNext},
+				{KeyID: "k0", Algorithm: JWTAlgorithmES256, PublicKeyFile: "k0.pub.pem", Status: JWTKeyStatusRetired, RetiredAt: &retiredAt},
+			},
+		},
+		{name: "no active key", keys: []JWTKeyEntry{}, wantErr: true},
+		{name: "two active keys", keys: []JWTKeyEntry{active, {KeyID: "k2", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k2.pem", Status: JWTKeyStatusActive}}, wantErr: true},
+		{name: "duplicate kid", keys: []JWTKeyEntry{active, {KeyID: "k1", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k1.pem", Status: JWTKeyStatusNext}}, wantErr: true},
+		{name: "missing kid", keys: []JWTKeyEntry{{Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k1.pem", Status: JWTKeyStatusActive}}, wantErr: true},
+		{name: "shared secret", keys: []JWTKeyEntry{{KeyID: "k1", Algorithm: JWTAlgorithmHS256, PrivateKeyFile: "k1.pem", Status: JWTKeyStatusActive}}, wantErr: true},
+		{name: "next key without private key", keys: []JWTKeyEntry{active, {KeyID: "k2", Algorithm: JWTAlgorithmES256, PublicKeyFile: "k2.pub.pem", Status: JWTKeyStatusNext}}, wantErr: true},
+		{name: "retired key without retired_at", keys: []JWTKeyEntry{active, {KeyID: "k0", Algorithm: JWTAlgorithmES256, PublicKeyFile: "k0.pub.pem", Status: JWTKeyStatusRetired}}, wantErr: true},
+		{name: "unknown status", keys: []JWTKeyEntry{active, {KeyID: "k2", Algorithm: JWTAlgorithmES256, PrivateKeyFile: "k2.pem", Status: "pending"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JWTKeyManifest{Keys: tt.keys}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTKeyRingRotation(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "jwt-keys.json")
+	const maxTokenLifetime = time.Hour
+
+	redisService, _ := newTestRedisService(t)
+	ctx := context.Background()
+
+	manifest := &JWTKeyManifest{}
+	save := func() *securityService {
+		t.Helper()
+		if err := SaveJWTKeyManifest(manifestPath, manifest); err != nil {
+			t.Fatalf("SaveJWTKeyManifest() error = %v", err)
+		}
+		keyRing, err := LoadJWTKeyRing(manifestPath, maxTokenLifetime)
+		if err != nil {
+			t.Fatalf("LoadJWTKeyRing() error = %v", err)
+		}
+		return newTestSecurityService(t, keyRing, redisService)
+	}
+
+	if err := manifest.Add(writeTestES256Key(t, dir, "k1")); err != nil {
+		t.Fatalf("Add(k1) error = %v", err)
+	}
+	s := save()
+	oldToken, _, err := s.GenerateJWT(ctx, "user-1", "user", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+
+	// A next key is published for verification but doesn't sign yet
+	if err := manifest.Add(writeTestES256Key(t, dir, "k2")); err != nil {
+		t.Fatalf("Add(k2) error = %v", err)
+	}
+	s = save()
+	if kid := s.config.KeyRing.SigningKey().KeyID; kid != "k1" {
+		t.Errorf("signing key after adding k2 = %s, want k1", kid)
+	}
+	if _, ok := s.config.KeyRing.VerificationKey("k2"); !ok {
+		t.Error("k2 is not in the key ring before it is promoted")
+	}
+
+	// After promotion k2 signs and tokens signed by k1 stay valid
+	if err := manifest.Promote("k2"); err != nil {
+		t.Fatalf("Promote(k2) error = %v", err)
+	}
+	s = save()
+	newToken, _, err := s.GenerateJWT(ctx, "user-1", "user", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+	if s.config.KeyRing.SigningKey().KeyID != "k2" {
+		t.Errorf("signing key after promotion = %s, want k2", s.config.KeyRing.SigningKey().KeyID)
+	}
+	for name, token := range map[string]string{"k1": oldToken, "k2": newToken} {
+		if _, err := s.ValidateJWT(ctx, token); err != nil {
+			t.Errorf("ValidateJWT() of a token signed by %s error = %v", name, err)
+		}
+	}
+
+	// Once k1's tokens have expired it is pruned and stops verifying
+	retiredAt := time.Now().Add(-2 * maxTokenLifetime)
+	manifest.find("k1").RetiredAt = &retiredAt
+	manifest.Prune(maxTokenLifetime)
+	s = save()
+	if _, err := s.ValidateJWT(ctx, oldToken); err == nil {
+		t.Error("ValidateJWT() accepted a token signed by a pruned key")
+	}
+	if _, err := s.ValidateJWT(ctx, newToken); err != nil {
+		t.Errorf("ValidateJWT() of a token signed by k2 error = %v", err)
+	}
+}
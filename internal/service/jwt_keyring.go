@@ -0,0 +1,123 @@
+// internal/service/jwt_keyring.go
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWTKeyRing holds the one key new tokens are signed with and every key a
+// token may still have been signed with, looked up by the kid header
+type JWTKeyRing struct {
+	signing *JWTSigningKey
+	keys    map[string]*JWTSigningKey
+	order   []string
+}
+
+// NewJWTKeyRing creates a key ring that signs with signing and also accepts
+// tokens signed by any of the verification keys
+func NewJWTKeyRing(signing *JWTSigningKey, verification ...*JWTSigningKey) (*JWTKeyRing, error) {
+	if signing == nil || signing.SignKey == nil {
+		return nil, fmt.Errorf("JWT key ring needs a signing key")
+	}
+
+	ring := &JWTKeyRing{
+		signing: signing,
+		keys:    make(map[string]*JWTSigningKey),
+	}
+	for _, key := range append([]*JWTSigningKey{signing}, verification...) {
+		if key.KeyID == "" {
+			return nil, fmt.Errorf("JWT key ID must not be empty")
+		}
+		if _, exists := ring.keys[key.KeyID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key ID %q", key.KeyID)
+		}
+		ring.keys[key.KeyID] = key
+		ring.order = append(ring.order, key.KeyID)
+	}
+
+	return ring, nil
+}
+
+// SigningKey returns the key new tokens are signed with
+func (r *JWTKeyRing) SigningKey() *JWTSigningKey {
+	return r.signing
+}
+
+// VerificationKey returns the key with the given kid
+func (r *JWTKeyRing) VerificationKey(keyID string) (*JWTSigningKey, bool) {
+	key, ok := r.keys[keyID]
+	return key, ok
+}
+
+// Algorithms returns the signing algorithms of the keys in the ring
+func (r *JWTKeyRing) Algorithms() []string {
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, keyID := range r.order {
+		alg := r.keys[keyID].Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring. HS256 keys are shared secrets
+// and are never published.
+func (r *JWTKeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, keyID := range r.order {
+		key := r.keys[keyID]
+		jwk := JWK{
+			Use:       "sig",
+			KeyID:     key.KeyID,
+			Algorithm: key.Method.Alg(),
+		}
+
+		switch public := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,163 @@
+// internal/service/jwt_keys.go
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported JWT signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256" // Shared secret, every verifier needs the secret
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmES256 = "ES256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// JWTSigningKey is the key tokens are signed and verified with. For the
+// asymmetric algorithms VerifyKey is the public key, which is all other
+// services need to validate tokens.
+type JWTSigningKey struct {
+	KeyID     string
+	Method    jwt.SigningMethod
+	SignKey   interface{}
+	VerifyKey interface{}
+}
+
+// NewHMACSigningKey creates an HS256 key from a shared secret
+func NewHMACSigningKey(keyID, secret string) (*JWTSigningKey, error) {
+	// Validate JWT secret length (at least 32 characters)
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("JWT secret is too short, must be at least 32 characters")
+	}
+
+	return &JWTSigningKey{
+		KeyID:     keyID,
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   []byte(secret),
+		VerifyKey: []byte(secret),
+	}, nil
+}
+
+// LoadJWTSigningKey loads an RS256, ES256 or EdDSA key pair from PEM files.
+// The public key is derived from the private key when publicKeyFile is
+// empty, otherwise it must belong to the private key.
+func LoadJWTSigningKey(algorithm, keyID, privateKeyFile, publicKeyFile string) (*JWTSigningKey, error) {
+	privatePEM, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+
+	key := &JWTSigningKey{KeyID: keyID}
+	var public crypto.PublicKey
+
+	switch algorithm {
+	case JWTAlgorithmRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key must be at least 2048 bits")
+		}
+		key.Method, key.SignKey, public = jwt.SigningMethodRS256, private, &private.PublicKey
+	case JWTAlgorithmES256:
+		private, err := jwt.ParseECPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		if private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 needs a P-256 key")
+		}
+		key.Method, key.SignKey, public = jwt.SigningMethodES256, private, &private.PublicKey
+	case JWTAlgorithmEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("EdDSA needs an Ed25519 key")
+		}
+		key.Method, key.SignKey, public = jwt.SigningMethodEdDSA, edPrivate, edPrivate.Public()
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", algorithm)
+	}
+
+	if publicKeyFile != "" {
+		configured, err := loadJWTPublicKey(algorithm, publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if !publicKeysEqual(public, configured) {
+			return nil, fmt.Errorf("JWT public key doesn't belong to the private key")
+		}
+	}
+
+	key.VerifyKey = public
+	return key, nil
+}
+
+// LoadJWTVerificationKey loads only the public key of a key pair, enough to
+// verify tokens signed by a key that no longer signs
+func LoadJWTVerificationKey(algorithm, keyID, publicKeyFile string) (*JWTSigningKey, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil || algorithm == JWTAlgorithmHS256 {
+		return nil, fmt.Errorf("unsupported JWT verification algorithm %q", algorithm)
+	}
+
+	public, err := loadJWTPublicKey(algorithm, publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTSigningKey{
+		KeyID:     keyID,
+		Method:    method,
+		VerifyKey: public,
+	}, nil
+}
+
+// loadJWTPublicKey reads a PEM public key of the given algorithm
+func loadJWTPublicKey(algorithm, publicKeyFile string) (crypto.PublicKey, error) {
+	publicPEM, err := os.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT public key: %w", err)
+	}
+
+	var public crypto.PublicKey
+	switch algorithm {
+	case JWTAlgorithmRS256:
+		public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+	case JWTAlgorithmES256:
+		public, err = jwt.ParseECPublicKeyFromPEM(publicPEM)
+	case JWTAlgorithmEdDSA:
+		public, err = jwt.ParseEdPublicKeyFromPEM(publicPEM)
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+	}
+
+	return public, nil
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	switch key := a.(type) {
+	case *rsa.PublicKey:
+		return key.Equal(b)
+	case *ecdsa.PublicKey:
+		return key.Equal(b)
+	case ed25519.PublicKey:
+		return key.Equal(b)
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+// internal/service/jwt_keys_test.go
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// generateTestKey generates a private key for a JWT algorithm, curve picks
+// the ES256 curve and bits the RSA key size
+func generateTestKey(t *testing.T, algorithm string, curve elliptic.Curve, bits int) crypto.Signer {
+	t.Helper()
+
+	var (
+		key crypto.Signer
+		err error
+	)
+	switch algorithm {
+	case JWTAlgorithmRS256:
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case JWTAlgorithmES256:
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	case JWTAlgorithmEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("failed to generate %s key: %v", algorithm, err)
+	}
+	return key
+}
+
+// writeTestKeyPair writes key as <name>.pem and its public key as
+// <name>.pub.pem to dir and returns both paths
+func writeTestKeyPair(t *testing.T, dir, name string, key crypto.Signer) (string, string) {
+	t.Helper()
+
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to encode private key: %v", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+
+	privateFile := filepath.Join(dir, name+".pem")
+	publicFile := filepath.Join(dir, name+".pub.pem")
+	if err := os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return privateFile, publicFile
+}
+
+func TestLoadJWTSigningKey(t *testing.T) {
+	for _, algorithm := range []string{JWTAlgorithmRS256, JWTAlgorithmES256, JWTAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			privateFile, publicFile := writeTestKeyPair(t, dir, "current", generateTestKey(t, algorithm, elliptic.P256(), 2048))
+
+			// The public key is derived from the private key or checked against it
+			for _, configuredPublic := range []string{"", publicFile} {
+				key, err := LoadJWTSigningKey(algorithm, "current", privateFile, configuredPublic)
+				if err != nil {
+					t.Fatalf("LoadJWTSigningKey() error = %v", err)
+				}
+				if key.Method.Alg() != algorithm {
+					t.Errorf("Method = %s, want %s", key.Method.Alg(), algorithm)
+				}
+
+				keyRing, err := NewJWTKeyRing(key)
+				if err != nil {
+					t.Fatalf("NewJWTKeyRing() error = %v", err)
+				}
+				redisService, _ := newTestRedisService(t)
+				s := newTestSecurityService(t, keyRing, redisService)
+
+				token, _, err := s.GenerateJWT(context.Background(), "user-1", "user", time.Now())
+				if err != nil {
+					t.Fatalf("GenerateJWT() error = %v", err)
+				}
+				if claims, err := s.ValidateJWT(context.Background(), token); err != nil || claims["sub"] != "user-1" {
+					t.Errorf("ValidateJWT() = %v, %v, want the token accepted", claims, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadJWTSigningKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	esPrivate, _ := writeTestKeyPair(t, dir, "es256", generateTestKey(t, JWTAlgorithmES256, elliptic.P256(), 0))
+	_, otherPublic := writeTestKeyPair(t, dir, "other", generateTestKey(t, JWTAlgorithmES256, elliptic.P256(), 0))
+	p384Private, _ := writeTestKeyPair(t, dir, "p384", generateTestKey(t, JWTAlgorithmES256, elliptic.P384(), 0))
+	weakRSAPrivate, _ := writeTestKeyPair(t, dir, "rsa1024", generateTestKey(t, JWTAlgorithmRS256, nil, 1024))
+
+	tests := []struct {
+		name          string
+		algorithm     string
+		privateKey    string
+		publicKeyFile string
+	}{
+		{name: "public key of another pair", algorithm: JWTAlgorithmES256, privateKey: esPrivate, publicKeyFile: otherPublic},
+		{name: "key of another algorithm", algorithm: JWTAlgorithmRS256, privateKey: esPrivate},
+		{name: "ES256 with a P-384 key", algorithm: JWTAlgorithmES256, privateKey: p384Private},
+		{name: "RSA key under 2048 bits", algorithm: JWTAlgorithmRS256, privateKey: weakRSAPrivate},
+		{name: "unsupported algorithm", algorithm: "PS256", privateKey: esPrivate},
+		{name: "missing file", algorithm: JWTAlgorithmES256, privateKey: filepath.Join(dir, "missing.pem")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWTSigningKey(tt.algorithm, "kid", tt.privateKey, tt.publicKeyFile); err == nil {
+				t.Error("LoadJWTSigningKey() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadJWTVerificationKey(t *testing.T) {
+	dir := t.TempDir()
+	privateFile, publicFile := writeTestKeyPair(t, dir, "old", generateTestKey(t, JWTAlgorithmEdDSA, nil, 0))
+
+	if _, err := LoadJWTVerificationKey(JWTAlgorithmHS256, "old", publicFile); err == nil {
+		t.Error("LoadJWTVerificationKey(HS256) error = nil, want HS256 refused")
+	}
+
+	old, err := LoadJWTSigningKey(JWTAlgorithmEdDSA, "old", privateFile, "")
+	if err != nil {
+		t.Fatalf("LoadJWTSigningKey() error = %v", err)
+	}
+	verifyOnly, err := LoadJWTVerificationKey(JWTAlgorithmEdDSA, "old", publicFile)
+	if err != nil {
+		t.Fatalf("LoadJWTVerificationKey() error = %v", err)
+	}
+	if verifyOnly.SignKey != nil {
+		t.Error("a verification key can sign")
+	}
+
+	// A token of the old key still verifies after the switch to a new one
+	oldRing, err := NewJWTKeyRing(old)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing() error = %v", err)
+	}
+	redisService, _ := newTestRedisService(t)
+	token, _, err := newTestSecurityService(t, oldRing, redisService).GenerateJWT(context.Background(), "user-1", "user", time.Now())
+	if err != nil {
+		t.Fatalf("GenerateJWT() error = %v", err)
+	}
+
+	newPrivate, _ := writeTestKeyPair(t, dir, "new", generateTestKey(t, JWTAlgorithmES256, elliptic.P256(), 0))
+	current, err := LoadJWTSigningKey(JWTAlgorithmES256, "new", newPrivate, "")
+	if err != nil {
+		t.Fatalf("LoadJWTSigningKey() error = %v", err)
+	}
+	newRing, err := NewJWTKeyRing(current, verifyOnly)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing() error = %v", err)
+	}
+	if _, err := newTestSecurityService(t, newRing, redisService).ValidateJWT(context.Background(), token); err != nil {
+		t.Errorf("ValidateJWT() of the old key's token error = %v", err)
+	}
+}
+
+// A token must not be able to pick its own algorithm, e.g. HS256 with the
+// public key as the shared secret
+func TestValidateJWTRejectsAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+	privateFile, publicFile := writeTestKeyPair(t, dir, "current", generateTestKey(t, JWTAlgorithmRS256, nil, 2048))
+
+	key, err := LoadJWTSigningKey(JWTAlgorithmRS256, "current", privateFile, "")
+	if err != nil {
+		t.Fatalf("LoadJWTSigningKey() error = %v", err)
+	}
+	keyRing, err := NewJWTKeyRing(key)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing() error = %v", err)
+	}
+	redisService, _ := newTestRedisService(t)
+	s := newTestSecurityService(t, keyRing, redisService)
+
+	publicPEM, err := os.ReadFile(publicFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	claims := jwt.MapClaims{"sub": "user-1", "iss": "test", "exp": time.Now().Add(time.Hour).Unix(), "jti": "forged"}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+	}{
+		{name: "HS256 with the public key", method: jwt.SigningMethodHS256, key: publicPEM},
+		{name: "none", method: jwt.SigningMethodNone, key: jwt.UnsafeAllowNoneSignatureType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(tt.method, claims)
+			token.Header["kid"] = "current"
+			forged, err := token.SignedString(tt.key)
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+			if _, err := s.ValidateJWT(context.Background(), forged); err == nil {
+				t.Error("ValidateJWT() accepted a forged token")
+			}
+		})
+	}
+}
@@ -122,3 +122,28 @@ func (s *NoOpMetricsService) LogoutDuration(ctx context.Context, durationSeconds
 func (s *NoOpMetricsService) TokenRefreshDuration(ctx context.Context, durationSeconds float64) {
 	// Implementation depends on the metrics library being used
 }
+
+// IncEmailSent increments the delivered email counter
+func (s *NoOpMetricsService) IncEmailSent(ctx context.Context, template string) {
+	// Example: prometheus.EmailSentCounter.WithLabelValues(template).Inc()
+}
+
+// IncEmailRetry increments the email retry counter with reason
+func (s *NoOpMetricsService) IncEmailRetry(ctx context.Context, template string, reason string) {
+	// Example: prometheus.EmailRetryCounter.WithLabelValues(template, reason).Inc()
+}
+
+// IncEmailDeadLettered increments the dead-lettered email counter with reason
+func (s *NoOpMetricsService) IncEmailDeadLettered(ctx context.Context, template string, reason string) {
+	// Example: prometheus.EmailDeadLetterCounter.WithLabelValues(template, reason).Inc()
+}
+
+// EmailDeliveryDuration records the duration of an email delivery attempt
+func (s *NoOpMetricsService) EmailDeliveryDuration(ctx context.Context, seconds float64) {
+	// Example: prometheus.EmailDeliveryHistogram.Observe(seconds)
+}
+
+// EmailOutboxBacklog records the number of emails waiting for delivery
+func (s *NoOpMetricsService) EmailOutboxBacklog(ctx context.Context, pending int64) {
+	// Example: prometheus.EmailOutboxGauge.Set(float64(pending))
+}
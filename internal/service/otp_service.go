@@ -4,16 +4,43 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"time"
 
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
 	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/redis"
 )
 
+// ErrUnknownOTPPurpose is returned for a purpose without a configured policy
+var ErrUnknownOTPPurpose = errors.New("unknown OTP purpose")
+
+// OTPPolicy holds the settings of OTPs issued for one purpose
+type OTPPolicy struct {
+	Length      int
+	Expiry      time.Duration
+	MaxAttempts int // Failed guesses before an OTP is invalidated
+}
+
 // OTPConfig holds OTP service configuration
 type OTPConfig struct {
-	Length     int
-	ExpiryMins int
+	Length            int // Default length for GenerateOTP
+	Policies          map[model.OTPPurpose]OTPPolicy
+	ResendCooldownSec int // Minimum time between two resends for the same purpose and recipient
+	ResendDailyLimit  int // Resends allowed per purpose and recipient in 24 hours
+}
+
+// OTPResendError is returned when a resend is refused by the cooldown or the daily cap
+type OTPResendError struct {
+	LimitReached bool
+	RetryAfter   time.Duration
+}
+
+func (e *OTPResendError) Error() string {
+	if e.LimitReached {
+		return "daily OTP resend limit reached"
+	}
+	return "OTP resend cooldown active"
 }
 
 // Implementation of the OTPService interface
@@ -51,20 +78,38 @@ func (s *otpService) GenerateOTP(ctx context.Context, length int) (string, error
 	return string(buffer), nil
 }
 
-// GetOTPExpiryTime returns the configured expiry time for OTPs
-func (s *otpService) GetOTPExpiryTime(ctx context.Context) time.Time {
-	return time.Now().Add(time.Duration(s.config.ExpiryMins) * time.Minute)
+// GetPolicy returns the policy configured for a purpose
+func (s *otpService) GetPolicy(purpose model.OTPPurpose) (OTPPolicy, error) {
+	policy, ok := s.config.Policies[purpose]
+	if !ok {
+		return OTPPolicy{}, ErrUnknownOTPPurpose
+	}
+	return policy, nil
 }
 
-// GenerateAndStoreOTP generates a new OTP and stores it in Redis
-func (s *otpService) GenerateAndStoreOTP(ctx context.Context, key string) (string, error) {
-	otp, err := s.GenerateOTP(ctx, s.config.Length)
+// GetOTPExpiryTime returns when an OTP issued now for the purpose expires
+func (s *otpService) GetOTPExpiryTime(ctx context.Context, purpose model.OTPPurpose) (time.Time, error) {
+	policy, err := s.GetPolicy(purpose)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(policy.Expiry), nil
+}
+
+// GenerateAndStoreOTP generates a new OTP for a purpose and recipient and
+// stores it in Redis, replacing any previous OTP for the same pair
+func (s *otpService) GenerateAndStoreOTP(ctx context.Context, purpose model.OTPPurpose, recipient string) (string, error) {
+	policy, err := s.GetPolicy(purpose)
 	if err != nil {
 		return "", err
 	}
 
-	expiry := time.Duration(s.config.ExpiryMins) * time.Minute
-	err = s.otpRepo.StoreOTP(ctx, key, otp, expiry)
+	otp, err := s.GenerateOTP(ctx, policy.Length)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.otpRepo.StoreOTP(ctx, purpose, recipient, otp, policy.Expiry)
 	if err != nil {
 		// If Redis is unavailable, we still return the OTP
 		// This allows the system to continue working but OTP validation won't work
@@ -79,7 +124,36 @@ func (s *otpService) GenerateAndStoreOTP(ctx context.Context, key string) (strin
 	return otp, nil
 }
 
-// VerifyOTP verifies an OTP for a given key
-func (s *otpService) VerifyOTP(ctx context.Context, key string, otp string) (bool, error) {
-	return s.otpRepo.VerifyOTP(ctx, key, otp)
+// VerifyOTP verifies an OTP for a purpose and recipient. OTPs issued for
+// another purpose never match.
+func (s *otpService) VerifyOTP(ctx context.Context, purpose model.OTPPurpose, recipient string, otp string) (bool, error) {
+	policy, err := s.GetPolicy(purpose)
+	if err != nil {
+		return false, err
+	}
+	return s.otpRepo.VerifyOTP(ctx, purpose, recipient, otp, policy.MaxAttempts)
+}
+
+// ReserveResend checks the resend cooldown and daily cap for a purpose and
+// recipient and counts the resend if allowed. A refused resend returns an
+// *OTPResendError.
+func (s *otpService) ReserveResend(ctx context.Context, purpose model.OTPPurpose, recipient string) (*repository.OTPResendStatus, error) {
+	if _, err := s.GetPolicy(purpose); err != nil {
+		return nil, err
+	}
+
+	cooldown := time.Duration(s.config.ResendCooldownSec) * time.Second
+	status, err := s.otpRepo.ReserveResend(ctx, purpose, recipient, cooldown, s.config.ResendDailyLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.Allowed {
+		return status, &OTPResendError{
+			LimitReached: status.LimitReached,
+			RetryAfter:   status.RetryAfter,
+		}
+	}
+
+	return status, nil
 }
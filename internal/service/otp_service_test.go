@@ -0,0 +1,158 @@
+// internal/service/otp_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository/redis"
+	redisClient "github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/pkg/redis"
+)
+
+func newTestOTPService(t *testing.T, config OTPConfig) (OTPService, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	otpRepo := redis.NewOTPRepository(&redisClient.Client{Client: client}, "otp-secret")
+	return NewOTPService(config, otpRepo), server
+}
+
+// testOTPConfig has a short registration code and a longer password reset code
+func testOTPConfig() OTPConfig {
+	return OTPConfig{
+		Length: 6,
+		Policies: map[model.OTPPurpose]OTPPolicy{
+			model.OTPPurposeRegistration:  {Length: 6, Expiry: 15 * time.Minute, MaxAttempts: 2},
+			model.OTPPurposePasswordReset: {Length: 8, Expiry: 10 * time.Minute, MaxAttempts: 5},
+		},
+		ResendCooldownSec: 60,
+		ResendDailyLimit:  2,
+	}
+}
+
+func TestOTPServicePurposePolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		purpose    model.OTPPurpose
+		wantLength int
+		wantExpiry time.Duration
+		wantErr    error
+	}{
+		{name: "registration", purpose: model.OTPPurposeRegistration, wantLength: 6, wantExpiry: 15 * time.Minute},
+		{name: "password reset", purpose: model.OTPPurposePasswordReset, wantLength: 8, wantExpiry: 10 * time.Minute},
+		{name: "purpose without a policy", purpose: model.OTPPurposeLogin, wantErr: ErrUnknownOTPPurpose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, server := newTestOTPService(t, testOTPConfig())
+			ctx := context.Background()
+
+			otp, err := s.GenerateAndStoreOTP(ctx, tt.purpose, "user@example.com")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateAndStoreOTP() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(otp) != tt.wantLength {
+				t.Errorf("OTP %q has %d digits, want %d", otp, len(otp), tt.wantLength)
+			}
+			for _, r := range otp {
+				if r < '0' || r > '9' {
+					t.Errorf("OTP %q is not numeric", otp)
+					break
+				}
+			}
+			if ttl := server.TTL("otp:" + string(tt.purpose) + ":code:user@example.com"); ttl != tt.wantExpiry {
+				t.Errorf("OTP TTL = %v, want %v", ttl, tt.wantExpiry)
+			}
+
+			if valid, err := s.VerifyOTP(ctx, tt.purpose, "user@example.com", otp); err != nil || !valid {
+				t.Errorf("VerifyOTP() = %v, %v, want the code accepted", valid, err)
+			}
+		})
+	}
+}
+
+func TestOTPServiceVerifyOTP(t *testing.T) {
+	tests := []struct {
+		name      string
+		purpose   model.OTPPurpose // Purpose the code is verified for
+		guesses   int              // Wrong guesses before the real code
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "right purpose", purpose: model.OTPPurposeRegistration, wantValid: true},
+		{name: "after a wrong guess", purpose: model.OTPPurposeRegistration, guesses: 1, wantValid: true},
+		{name: "after the last attempt", purpose: model.OTPPurposeRegistration, guesses: 2},
+		{name: "other purpose", purpose: model.OTPPurposePasswordReset},
+		{name: "purpose without a policy", purpose: model.OTPPurposeLogin, wantErr: ErrUnknownOTPPurpose},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestOTPService(t, testOTPConfig())
+			ctx := context.Background()
+
+			otp, err := s.GenerateAndStoreOTP(ctx, model.OTPPurposeRegistration, "user@example.com")
+			if err != nil {
+				t.Fatalf("GenerateAndStoreOTP() error = %v", err)
+			}
+
+			for i := 1; i <= tt.guesses; i++ {
+				_, err := s.VerifyOTP(ctx, model.OTPPurposeRegistration, "user@example.com", "000000")
+				if i == 2 && !errors.Is(err, redis.ErrTooManyOTPAttempts) {
+					t.Errorf("VerifyOTP() of the last attempt error = %v, want %v", err, redis.ErrTooManyOTPAttempts)
+				}
+			}
+
+			valid, err := s.VerifyOTP(ctx, tt.purpose, "user@example.com", otp)
+			if valid != tt.wantValid || !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyOTP() = %v, %v, want %v, %v", valid, err, tt.wantValid, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOTPServiceReserveResend(t *testing.T) {
+	s, server := newTestOTPService(t, testOTPConfig())
+	ctx := context.Background()
+
+	status, err := s.ReserveResend(ctx, model.OTPPurposeRegistration, "user@example.com")
+	if err != nil || !status.Allowed || status.Remaining != 1 {
+		t.Fatalf("ReserveResend() = %+v, %v, want allowed with 1 left", status, err)
+	}
+
+	var resendErr *OTPResendError
+	_, err = s.ReserveResend(ctx, model.OTPPurposeRegistration, "user@example.com")
+	if !errors.As(err, &resendErr) || resendErr.LimitReached || resendErr.RetryAfter <= 0 {
+		t.Errorf("ReserveResend() during the cooldown error = %v, want a cooldown", err)
+	}
+
+	// Other purposes have a cooldown of their own
+	if _, err := s.ReserveResend(ctx, model.OTPPurposePasswordReset, "user@example.com"); err != nil {
+		t.Errorf("ReserveResend() for another purpose error = %v", err)
+	}
+
+	server.FastForward(time.Minute)
+	if _, err := s.ReserveResend(ctx, model.OTPPurposeRegistration, "user@example.com"); err != nil {
+		t.Fatalf("ReserveResend() after the cooldown error = %v", err)
+	}
+
+	server.FastForward(time.Minute)
+	_, err = s.ReserveResend(ctx, model.OTPPurposeRegistration, "user@example.com")
+	if !errors.As(err, &resendErr) || !resendErr.LimitReached {
+		t.Errorf("ReserveResend() past the daily limit error = %v, want the limit reached", err)
+	}
+}
@@ -0,0 +1,82 @@
+// internal/service/outbox_cipher.go
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// outboxPayloadVersion prefixes sealed payloads so the format can change later
+const outboxPayloadVersion = "v1."
+
+// OutboxPayloadCipher encrypts the template data of queued emails with
+// AES-256-GCM. The data may hold one-time codes and sits in the database
+// until the email is sent, which can take hours when delivery keeps failing.
+type OutboxPayloadCipher struct {
+	aead cipher.AEAD
+}
+
+// NewOutboxPayloadCipher creates a cipher from a hex-encoded 256-bit key
+func NewOutboxPayloadCipher(hexKey string) (*OutboxPayloadCipher, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("outbox payload key must be 64 hex characters")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxPayloadCipher{aead: aead}, nil
+}
+
+// Seal encrypts the payload of the outbox message with the given ID. The
+// result is a JSON string, as the payload column is jsonb, and the ID is
+// authenticated so a payload can't be moved to another message.
+func (c *OutboxPayloadCipher) Seal(id uuid.UUID, payload []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, payload, id[:])
+	encoded, err := json.Marshal(outboxPayloadVersion + base64.StdEncoding.EncodeToString(sealed))
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// Open decrypts a payload sealed for the outbox message with the given ID
+func (c *OutboxPayloadCipher) Open(id uuid.UUID, stored string) ([]byte, error) {
+	var encoded string
+	if err := json.Unmarshal([]byte(stored), &encoded); err != nil || !strings.HasPrefix(encoded, outboxPayloadVersion) {
+		return nil, errors.New("payload is not sealed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, outboxPayloadVersion))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, errors.New("payload is malformed")
+	}
+
+	nonceSize := c.aead.NonceSize()
+	payload, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], id[:])
+	if err != nil {
+		return nil, errors.New("payload failed authentication")
+	}
+	return payload, nil
+}
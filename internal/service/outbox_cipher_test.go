@@ -0,0 +1,95 @@
+// internal/service/outbox_cipher_test.go
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestOutboxPayloadCipher(t *testing.T) {
+	payloadCipher := testOutboxCipher(t)
+	otherCipher, err := NewOutboxPayloadCipher(strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatalf("NewOutboxPayloadCipher() error = %v", err)
+	}
+
+	id := uuid.New()
+	payload := []byte(`{"OTP":"123456"}`)
+	sealed, err := payloadCipher.Seal(id, payload)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if strings.Contains(sealed, "123456") {
+		t.Fatalf("Seal() = %s, the payload is readable", sealed)
+	}
+
+	// Swap a base64 character in the middle of the ciphertext
+	tampered := []byte(sealed)
+	if mid := len(tampered) / 2; tampered[mid] == 'A' {
+		tampered[mid] = 'B'
+	} else {
+		tampered[mid] = 'A'
+	}
+
+	tests := []struct {
+		name    string
+		cipher  *OutboxPayloadCipher
+		id      uuid.UUID
+		stored  string
+		wantErr bool
+	}{
+		{name: "sealed for the message", cipher: payloadCipher, id: id, stored: sealed},
+		{name: "sealed for another message", cipher: payloadCipher, id: uuid.New(), stored: sealed, wantErr: true},
+		{name: "other key", cipher: otherCipher, id: id, stored: sealed, wantErr: true},
+		{name: "tampered", cipher: payloadCipher, id: id, stored: string(tampered), wantErr: true},
+		{name: "plain JSON", cipher: payloadCipher, id: id, stored: string(payload), wantErr: true},
+		{name: "cleared", cipher: payloadCipher, id: id, stored: "{}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cipher.Open(tt.id, tt.stored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != string(payload) {
+				t.Errorf("Open() = %s, want %s", got, payload)
+			}
+		})
+	}
+}
+
+func TestNewOutboxPayloadCipherKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "256-bit key", key: strings.Repeat("ab", 32)},
+		{name: "128-bit key", key: strings.Repeat("ab", 16), wantErr: true},
+		{name: "not hex", key: strings.Repeat("zz", 32), wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewOutboxPayloadCipher(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("NewOutboxPayloadCipher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmailServiceQueueEmailEncryptsPayload(t *testing.T) {
+	emailService, _, repo, _ := newTestEmailOutbox(t, EmailDispatcherConfig{})
+
+	if err := emailService.QueueVerificationEmail(context.Background(), "user@example.com", "123456"); err != nil {
+		t.Fatalf("QueueVerificationEmail() error = %v", err)
+	}
+	if payload := repo.messages[0].Payload; strings.Contains(payload, "123456") {
+		t.Errorf("stored payload %s holds the OTP in the clear", payload)
+	}
+}
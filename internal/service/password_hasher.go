@@ -0,0 +1,183 @@
+// internal/service/password_hasher.go
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported password hashing algorithms
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+var ErrUnsupportedPasswordHash = errors.New("unsupported password hash format")
+
+// Argon2Params holds the argon2id cost parameters
+type Argon2Params struct {
+	Memory      uint32 // Memory in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHasherConfig selects the algorithm new hashes are created with
+type PasswordHasherConfig struct {
+	Algorithm  string // PasswordHashArgon2id or PasswordHashBcrypt
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// passwordHasher creates hashes with the configured algorithm and verifies
+// hashes of every supported algorithm. argon2id hashes are PHC strings
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash), bcrypt hashes keep their
+// standard $2a$/$2b$ form, so both carry everything needed to verify them.
+type passwordHasher struct {
+	config PasswordHasherConfig
+}
+
+// NewPasswordHasher creates a PasswordHasher for the configured algorithm
+func NewPasswordHasher(config PasswordHasherConfig) (PasswordHasher, error) {
+	switch config.Algorithm {
+	case PasswordHashArgon2id:
+		if config.Argon2.SaltLength == 0 {
+			config.Argon2.SaltLength = 16
+		}
+		if config.Argon2.KeyLength == 0 {
+			config.Argon2.KeyLength = 32
+		}
+		if config.Argon2.Iterations < 1 || config.Argon2.Parallelism < 1 {
+			return nil, fmt.Errorf("argon2id iterations and parallelism must be at least 1")
+		}
+		if config.Argon2.Memory < 8*uint32(config.Argon2.Parallelism) {
+			return nil, fmt.Errorf("argon2id memory must be at least 8 KiB per thread")
+		}
+	case PasswordHashBcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", config.Algorithm)
+	}
+
+	return &passwordHasher{config: config}, nil
+}
+
+// Hash hashes a password with the current algorithm and parameters
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == PasswordHashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	params := h.config.Argon2
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks a password against a hash of any supported algorithm
+func (h *passwordHasher) Verify(hash, password string) (bool, error) {
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+// NeedsRehash reports whether a hash was made with another algorithm or
+// other parameters than the current ones
+func (h *passwordHasher) NeedsRehash(hash string) bool {
+	if isBcryptHash(hash) {
+		if h.config.Algorithm != PasswordHashBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.config.BcryptCost
+	}
+
+	if h.config.Algorithm != PasswordHashArgon2id {
+		return true
+	}
+
+	params, salt, _, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	current := h.config.Argon2
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		params.KeyLength != current.KeyLength ||
+		uint32(len(salt)) != current.SaltLength
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// decodeArgon2idHash parses an argon2id PHC string
+func decodeArgon2idHash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Iterations < 1 || params.Parallelism < 1 {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,138 @@
+// internal/service/password_hasher_test.go
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters, the hasher doesn't care how strong they are
+var (
+	testArgon2Config = PasswordHasherConfig{
+		Algorithm: PasswordHashArgon2id,
+		Argon2:    Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1},
+	}
+	testBcryptConfig = PasswordHasherConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost}
+)
+
+func newTestPasswordHasher(t *testing.T, config PasswordHasherConfig) PasswordHasher {
+	t.Helper()
+
+	hasher, err := NewPasswordHasher(config)
+	if err != nil {
+		t.Fatalf("NewPasswordHasher() error = %v", err)
+	}
+	return hasher
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PasswordHasherConfig
+		wantErr bool
+	}{
+		{name: "argon2id", config: testArgon2Config},
+		{name: "bcrypt", config: testBcryptConfig},
+		{name: "argon2id without iterations", config: PasswordHasherConfig{Algorithm: PasswordHashArgon2id, Argon2: Argon2Params{Memory: 64, Parallelism: 1}}, wantErr: true},
+		{name: "argon2id with too little memory", config: PasswordHasherConfig{Algorithm: PasswordHashArgon2id, Argon2: Argon2Params{Memory: 15, Iterations: 1, Parallelism: 2}}, wantErr: true},
+		{name: "bcrypt cost too low", config: PasswordHasherConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost - 1}, wantErr: true},
+		{name: "unknown algorithm", config: PasswordHasherConfig{Algorithm: "md5"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPasswordHasher(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("NewPasswordHasher() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	argon2Hasher := newTestPasswordHasher(t, testArgon2Config)
+	bcryptHasher := newTestPasswordHasher(t, testBcryptConfig)
+
+	argon2Hash, err := argon2Hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(argon2Hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want an argon2id PHC string", argon2Hash)
+	}
+	bcryptHash, err := bcryptHasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+		wantErr  error
+	}{
+		{name: "argon2id", hash: argon2Hash, password: "correct horse battery staple", want: true},
+		{name: "argon2id wrong password", hash: argon2Hash, password: "Correct horse battery staple"},
+		{name: "bcrypt", hash: bcryptHash, password: "correct horse battery staple", want: true},
+		{name: "bcrypt wrong password", hash: bcryptHash, password: "correct horse battery stapler"},
+		{name: "malformed argon2id", hash: "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5", password: "x", wantErr: ErrUnsupportedPasswordHash},
+		{name: "other argon2 version", hash: "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5", password: "x", wantErr: ErrUnsupportedPasswordHash},
+		{name: "unknown algorithm", hash: "$1$salt$hash", password: "x", wantErr: ErrUnsupportedPasswordHash},
+	}
+
+	// Either hasher verifies hashes of both algorithms
+	for _, hasher := range []PasswordHasher{argon2Hasher, bcryptHasher} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := hasher.Verify(tt.hash, tt.password)
+				if got != tt.want || !errors.Is(err, tt.wantErr) {
+					t.Errorf("Verify() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	hashWith := func(config PasswordHasherConfig) string {
+		hash, err := newTestPasswordHasher(t, config).Hash("correct horse battery staple")
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return hash
+	}
+
+	moreMemory := testArgon2Config
+	moreMemory.Argon2.Memory = 128
+	longerSalt := testArgon2Config
+	longerSalt.Argon2.SaltLength = 32
+	higherCost := testBcryptConfig
+	higherCost.BcryptCost = bcrypt.MinCost + 1
+
+	tests := []struct {
+		name    string
+		current PasswordHasherConfig
+		hash    string
+		want    bool
+	}{
+		{name: "current argon2id", current: testArgon2Config, hash: hashWith(testArgon2Config)},
+		{name: "current bcrypt", current: testBcryptConfig, hash: hashWith(testBcryptConfig)},
+		{name: "bcrypt after the switch to argon2id", current: testArgon2Config, hash: hashWith(testBcryptConfig), want: true},
+		{name: "argon2id after a switch to bcrypt", current: testBcryptConfig, hash: hashWith(testArgon2Config), want: true},
+		{name: "argon2id memory raised", current: moreMemory, hash: hashWith(testArgon2Config), want: true},
+		{name: "argon2id salt lengthened", current: longerSalt, hash: hashWith(testArgon2Config), want: true},
+		{name: "bcrypt cost raised", current: higherCost, hash: hashWith(testBcryptConfig), want: true},
+		{name: "unreadable hash", current: testArgon2Config, hash: "garbage", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestPasswordHasher(t, tt.current).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// internal/service/password_history_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/repository"
+)
+
+// Implementation of the PasswordHistoryService interface
+type passwordHistoryService struct {
+	repo            repository.PasswordHistoryRepository
+	securityService SecurityService
+	depth           int
+}
+
+// NewPasswordHistoryService creates a password history service that
+// remembers the depth most recent previous passwords of every user
+func NewPasswordHistoryService(repo repository.PasswordHistoryRepository, securityService SecurityService, depth int) PasswordHistoryService {
+	if depth < 0 {
+		depth = 0
+	}
+	return &passwordHistoryService{
+		repo:            repo,
+		securityService: securityService,
+		depth:           depth,
+	}
+}
+
+// IsReused reports whether password is the user's current password or one of
+// the remembered previous ones. Hashes are compared with the password hasher,
+// so entries of any supported algorithm are matched.
+func (s *passwordHistoryService) IsReused(ctx context.Context, user *model.User, password string) (bool, error) {
+	if s.securityService.VerifyPassword(ctx, user.PasswordHash, password) {
+		return true, nil
+	}
+
+	entries, err := s.repo.ListRecent(ctx, user.ID, s.depth)
+	if err != nil {
+		return false, fmt.Errorf("failed to load password history: %w", err)
+	}
+
+	for _, entry := range entries {
+		if s.securityService.VerifyPassword(ctx, entry.PasswordHash, password) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Record remembers the hash a user is moving away from and forgets entries
+// beyond the configured depth, within the transaction in ctx if there is one
+func (s *passwordHistoryService) Record(ctx context.Context, userID uuid.UUID, previousHash string) error {
+	if s.depth > 0 {
+		entry := &model.PasswordHistory{
+			UserID:       userID,
+			PasswordHash: previousHash,
+			CreatedAt:    time.Now(),
+		}
+		if err := s.repo.Add(ctx, entry); err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+	}
+
+	if err := s.repo.Prune(ctx, userID, s.depth); err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+
+	return nil
+}
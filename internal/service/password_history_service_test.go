@@ -0,0 +1,100 @@
+// internal/service/password_history_service_test.go
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/model"
+)
+
+func TestPasswordHistoryService(t *testing.T) {
+	security := newTestSecurityService(t, testHMACKeyRing(t), nil)
+	ctx := context.Background()
+
+	hash := func(password string) string {
+		t.Helper()
+		h, err := security.HashPassword(ctx, password)
+		if err != nil {
+			t.Fatalf("HashPassword() error = %v", err)
+		}
+		return h
+	}
+
+	// The user moved through first, second and third, and uses current now
+	passwords := []string{"first password", "second password", "third password"}
+
+	tests := []struct {
+		name       string
+		depth      int
+		wantReused map[string]bool
+	}{
+		{
+			name:       "no history",
+			depth:      0,
+			wantReused: map[string]bool{"current password": true, "third password": false, "first password": false},
+		},
+		{
+			name:       "last two remembered",
+			depth:      2,
+			wantReused: map[string]bool{"current password": true, "third password": true, "second password": true, "first password": false},
+		},
+		{
+			name:       "deeper than the history",
+			depth:      5,
+			wantReused: map[string]bool{"third password": true, "first password": true, "fresh password": false},
+		},
+		{
+			name:       "negative depth",
+			depth:      -1,
+			wantReused: map[string]bool{"current password": true, "third password": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePasswordHistoryRepository{}
+			s := NewPasswordHistoryService(repo, security, tt.depth)
+			user := &model.User{ID: uuid.New()}
+
+			// Another user's history must neither count nor be pruned
+			other := uuid.New()
+			if err := s.Record(ctx, other, hash("third password")); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+
+			for _, password := range passwords {
+				if err := s.Record(ctx, user.ID, hash(password)); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+			user.PasswordHash = hash("current password")
+
+			wantKept := tt.depth
+			if wantKept < 0 {
+				wantKept = 0
+			}
+			if wantKept > len(passwords) {
+				wantKept = len(passwords)
+			}
+			if kept, _ := repo.ListRecent(ctx, user.ID, 10); len(kept) != wantKept {
+				t.Errorf("history holds %d entries, want %d", len(kept), wantKept)
+			}
+			if others, _ := repo.ListRecent(ctx, other, 10); tt.depth > 0 && len(others) != 1 {
+				t.Errorf("other user's history holds %d entries, want 1", len(others))
+			}
+
+			for password, want := range tt.wantReused {
+				reused, err := s.IsReused(ctx, user, password)
+				if err != nil {
+					t.Fatalf("IsReused() error = %v", err)
+				}
+				if reused != want {
+					t.Errorf("IsReused(%q) = %v, want %v", password, reused, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// internal/service/password_strength.go
+package service
+
+import (
+	"context"
+	"strings"
+
+	zxcvbn "github.com/ccojocar/zxcvbn-go"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// Password strength scores run from 0 (guessed almost instantly) to 4 (very
+// unlikely to be guessed), as in zxcvbn
+const MaxPasswordScore = 4
+
+// PasswordCheck is the outcome of validating a password
+type PasswordCheck struct {
+	Valid       bool
+	Reason      string   // Why the password was rejected, translated
+	Score       int      // Estimated strength, 0-4
+	Suggestions []string // How to pick a stronger password, translated
+}
+
+// PasswordValidationError is returned when a new password is rejected
+type PasswordValidationError struct {
+	Check PasswordCheck
+}
+
+func (e *PasswordValidationError) Error() string {
+	return "password validation failed: " + e.Check.Reason
+}
+
+// userInputMatches is the dictionary name zxcvbn gives matches of the user's own data
+const userInputMatches = "user_inputs"
+
+// estimatePasswordStrength scores a password by how it would be guessed:
+// dictionary words, keyboard patterns, repeats, sequences and dates count far
+// less than random characters, and so does anything in userInputs
+func estimatePasswordStrength(ctx context.Context, password string, userInputs []string) (int, []string) {
+	result := zxcvbn.PasswordStrength(password, passwordUserInputs(userInputs))
+
+	var suggestionIDs []string
+	add := func(id string) {
+		for _, existing := range suggestionIDs {
+			if existing == id {
+				return
+			}
+		}
+		suggestionIDs = append(suggestionIDs, id)
+	}
+
+	for _, m := range result.MatchSequence {
+		switch m.Pattern {
+		case "dictionary":
+			if m.DictionaryName == userInputMatches {
+				add("password.suggestion.personal_info")
+			} else {
+				add("password.suggestion.common_words")
+			}
+		case "spatial":
+			add("password.suggestion.keyboard_pattern")
+		case "repeat":
+			add("password.suggestion.repeats")
+		case "sequence":
+			add("password.suggestion.sequences")
+		case "date":
+			add("password.suggestion.dates")
+		}
+	}
+	if result.Score < MaxPasswordScore {
+		add("password.suggestion.longer")
+	}
+
+	suggestions := make([]string, 0, len(suggestionIDs))
+	for _, id := range suggestionIDs {
+		suggestions = append(suggestions, i18n.T(ctx, id))
+	}
+
+	return result.Score, suggestions
+}
+
+// passwordUserInputs expands the user's own data into the pieces people tend
+// to build passwords from, e.g. the local part and domain words of an email
+// and the trailing digits of a phone number
+func passwordUserInputs(values []string) []string {
+	var inputs []string
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		inputs = append(inputs, value)
+
+		if local, domain, ok := strings.Cut(value, "@"); ok {
+			inputs = append(inputs, local)
+			inputs = append(inputs, strings.FieldsFunc(local, isNameSeparator)...)
+			if name, _, ok := strings.Cut(domain, "."); ok {
+				inputs = append(inputs, name)
+			}
+			continue
+		}
+
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+		if len(digits) >= 6 {
+			inputs = append(inputs, digits, digits[len(digits)-6:], digits[len(digits)-4:])
+		}
+	}
+	return inputs
+}
+
+func isNameSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-' || r == '+'
+}
@@ -13,12 +13,110 @@ import (
 
 // Common Redis key prefixes
 const (
-	RefreshTokenPrefix  = "refresh_token:"
-	BlacklistPrefix     = "blacklist:"
-	LoginAttemptsPrefix = "login_attempts:"
-	LoginHistoryPrefix  = "login_history:"
+	RefreshTokenPrefix      = "refresh_token:"
+	BlacklistPrefix         = "blacklist:"
+	LoginAttemptsPrefix     = "login_attempts:"
+	LoginHistoryPrefix      = "login_history:"
+	UserRefreshTokensPrefix = "user_refresh_tokens:"   // A user's refresh token IDs, sorted by issue time in milliseconds
+	TokenFamilyPrefix       = "refresh_token_family:"  // Current refresh token ID of a family, or revokedFamily
+	RotatedTokenPrefix      = "rotated_refresh_token:" // Family of a refresh token that was already used
+	RevokedBeforePrefix     = "tokens_revoked_before:" // Tokens of a user issued before this Unix time in milliseconds are revoked
 )
 
+// revokedFamily marks a revoked token family in place of its current token
+// ID, so a rotation that was already under way can't store a new token
+const revokedFamily = "revoked"
+
+// ErrTokenFamilyRevoked is returned when a refresh token is stored into a
+// family that was revoked
+var ErrTokenFamilyRevoked = errors.New("refresh token family revoked")
+
+// ErrSessionLimitReached is returned when a new session would exceed the
+// session limit and the reject policy is used
+var ErrSessionLimitReached = errors.New("session limit reached")
+
+// storeRefreshTokenScript stores a refresh token, indexes it under its user
+// and makes it the current token of its family, unless the family was
+// revoked. With a session limit, room for the token is made in the same step:
+// the least recently issued tokens are deleted and their access tokens
+// blacklisted, or nothing is stored when evicting isn't allowed.
+// KEYS: token, user index, family (optional). ARGV: token data, TTL in ms,
+// token ID, tombstone, issue time in ms, session limit (0 for none), "1" to
+// evict, refresh token prefix, blacklist prefix, blacklist TTL in ms.
+// Returns {1, evicted token data...} when stored, {0} if the family was
+// revoked and {-1} if the session limit was reached.
+var storeRefreshTokenScript = redis.NewScript(`
+if KEYS[3] and redis.call('GET', KEYS[3]) == ARGV[4] then
+	return {0}
+end
+
+local evicted = {}
+local limit = tonumber(ARGV[6])
+if limit > 0 then
+	local live = {}
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+		local data = redis.call('GET', ARGV[8] .. id)
+		if data then
+			table.insert(live, {id, data})
+		else
+			redis.call('ZREM', KEYS[2], id)
+		end
+	end
+
+	-- One slot is needed for the new token
+	local excess = #live - limit + 1
+	if excess > 0 and ARGV[7] ~= '1' then
+		return {-1}
+	end
+	for i = 1, excess do
+		local id, data = live[i][1], live[i][2]
+		redis.call('DEL', ARGV[8] .. id)
+		redis.call('ZREM', KEYS[2], id)
+		local token = cjson.decode(data)
+		if type(token.access_token_id) == 'string' and token.access_token_id ~= '' then
+			redis.call('SET', ARGV[9] .. token.access_token_id, '1', 'PX', ARGV[10])
+		end
+		table.insert(evicted, data)
+	end
+end
+
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[5], ARGV[3])
+redis.call('PEXPIRE', KEYS[2], ARGV[2])
+if KEYS[3] then
+	redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[2])
+end
+return {1, unpack(evicted)}
+`)
+
+// revokeTokenFamilyScript replaces the current token ID of a family with the
+// tombstone, deletes that token and blacklists the access token issued with
+// it. The token's keys are only known from its data, so they are built from
+// prefixes. KEYS: family. ARGV: tombstone, family TTL in ms, refresh token
+// prefix, user index prefix, blacklist prefix, blacklist TTL in ms. Returns
+// the revoked token's data, nil if the family had no live token left.
+var revokeTokenFamilyScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+if not current or current == ARGV[1] then
+	return false
+end
+
+local tokenKey = ARGV[3] .. current
+local data = redis.call('GET', tokenKey)
+if not data then
+	return false
+end
+
+redis.call('DEL', tokenKey)
+local token = cjson.decode(data)
+redis.call('ZREM', ARGV[4] .. token.user_id, current)
+if type(token.access_token_id) == 'string' and token.access_token_id ~= '' then
+	redis.call('SET', ARGV[5] .. token.access_token_id, '1', 'PX', ARGV[6])
+end
+return data
+`)
+
 // TokenData represents data stored with a refresh token
 type TokenData struct {
 	UserID    string    `json:"user_id"`
@@ -27,6 +125,22 @@ type TokenData struct {
 	IssuedAt  time.Time `json:"issued_at"`
 	UserAgent string    `json:"user_agent"`
 	ClientIP  string    `json:"client_ip"`
+	// AccessTokenID is the jti of the access token issued with this refresh token
+	AccessTokenID string `json:"access_token_id,omitempty"`
+	// FamilyID is shared by every refresh token rotated from the same login
+	FamilyID string `json:"family_id,omitempty"`
+	// SessionStartedAt is when the user logged in, IssuedAt moves with every refresh
+	SessionStartedAt time.Time `json:"session_started_at,omitempty"`
+}
+
+// SessionID identifies the login session a refresh token belongs to. It
+// stays the same across rotations, except for tokens issued before token
+// families, which are their own session.
+func (d TokenData) SessionID() string {
+	if d.FamilyID != "" {
+		return d.FamilyID
+	}
+	return d.TokenID
 }
 
 // RedisServiceConfig holds Redis configuration
@@ -35,6 +149,7 @@ type RedisServiceConfig struct {
 	Password     string
 	DB           int
 	TokenExpiry  time.Duration
+	AccessExpiry time.Duration // How long a revoked access token stays blacklisted
 	ThrottleRate int           // Max attempts per minute
 	ThrottleTTL  time.Duration // How long throttling lasts
 }
@@ -61,21 +176,117 @@ func NewRedisService(config RedisServiceConfig, logger *logger.Logger) RedisServ
 	}
 }
 
-// StoreRefreshToken stores a refresh token with associated data
-func (s *redisService) StoreRefreshToken(ctx context.Context, tokenID, token string, data TokenData) error {
+// StoreRefreshToken stores a refresh token with associated data. It returns
+// ErrTokenFamilyRevoked if the token's family was revoked meanwhile.
+func (s *redisService) StoreRefreshToken(ctx context.Context, tokenID string, data TokenData) error {
+	_, err := s.storeRefreshToken(ctx, tokenID, data, 0, false)
+	return err
+}
+
+// StoreRefreshTokenWithinLimit stores the refresh token of a new session if
+// the user has fewer than limit tokens. Otherwise, in the same step, the
+// least recently issued tokens are revoked to make room and returned when
+// evict is set, or ErrSessionLimitReached is returned and nothing stored.
+func (s *redisService) StoreRefreshTokenWithinLimit(ctx context.Context, tokenID string, data TokenData, limit int, evict bool) ([]TokenData, error) {
+	return s.storeRefreshToken(ctx, tokenID, data, limit, evict)
+}
+
+func (s *redisService) storeRefreshToken(ctx context.Context, tokenID string, data TokenData, limit int, evict bool) ([]TokenData, error) {
 	// Convert data to JSON
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal token data: %w", err)
+		return nil, fmt.Errorf("failed to marshal token data: %w", err)
 	}
 
-	// Store token data with expiry
-	key := RefreshTokenPrefix + tokenID
-	if err := s.client.Set(ctx, key, jsonData, s.config.TokenExpiry).Err(); err != nil {
-		return fmt.Errorf("failed to store refresh token: %w", err)
+	// Store token data with expiry and index it under the user, the index
+	// lives as long as the newest token
+	keys := []string{RefreshTokenPrefix + tokenID, UserRefreshTokensPrefix + data.UserID}
+	if data.FamilyID != "" {
+		keys = append(keys, TokenFamilyPrefix+data.FamilyID)
 	}
 
-	return nil
+	evictFlag := "0"
+	if evict {
+		evictFlag = "1"
+	}
+
+	result, err := storeRefreshTokenScript.Run(ctx, s.client, keys,
+		jsonData, s.config.TokenExpiry.Milliseconds(), tokenID, revokedFamily,
+		data.IssuedAt.UnixMilli(), limit, evictFlag,
+		RefreshTokenPrefix, BlacklistPrefix, s.config.AccessExpiry.Milliseconds()).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	switch status, _ := result[0].(int64); status {
+	case 0:
+		return nil, ErrTokenFamilyRevoked
+	case -1:
+		return nil, ErrSessionLimitReached
+	}
+
+	evicted := make([]TokenData, 0, len(result)-1)
+	for _, raw := range result[1:] {
+		var tokenData TokenData
+		if err := json.Unmarshal([]byte(raw.(string)), &tokenData); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal token data: %w", err)
+		}
+		evicted = append(evicted, tokenData)
+	}
+	return evicted, nil
+}
+
+// ConsumeRefreshToken deletes a refresh token that is being rotated and
+// remembers it was used, so a replay can be told apart from an expired token.
+// It reports false if the token was already gone, e.g. a concurrent request
+// used it first.
+func (s *redisService) ConsumeRefreshToken(ctx context.Context, data TokenData) (bool, error) {
+	pipe := s.client.TxPipeline()
+	deleted := pipe.Del(ctx, RefreshTokenPrefix+data.TokenID)
+	pipe.ZRem(ctx, UserRefreshTokensPrefix+data.UserID, data.TokenID)
+	if data.FamilyID != "" {
+		pipe.Set(ctx, RotatedTokenPrefix+data.TokenID, data.FamilyID, s.config.TokenExpiry)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+	return deleted.Val() == 1, nil
+}
+
+// GetRotatedTokenFamily returns the family of a refresh token that was
+// already rotated, or an empty string if the token was never used
+func (s *redisService) GetRotatedTokenFamily(ctx context.Context, tokenID string) (string, error) {
+	familyID, err := s.client.Get(ctx, RotatedTokenPrefix+tokenID).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get refresh token family: %w", err)
+	}
+	return familyID, nil
+}
+
+// RevokeTokenFamily deletes the current refresh token of a family and
+// blacklists the access token issued with it, in one step with marking the
+// family revoked so no concurrent rotation can add a token to it. It returns
+// the revoked token's data, nil if the family had no live token left.
+func (s *redisService) RevokeTokenFamily(ctx context.Context, familyID string) (*TokenData, error) {
+	data, err := revokeTokenFamilyScript.Run(ctx, s.client, []string{TokenFamilyPrefix + familyID},
+		revokedFamily, s.config.TokenExpiry.Milliseconds(),
+		RefreshTokenPrefix, UserRefreshTokensPrefix,
+		BlacklistPrefix, s.config.AccessExpiry.Milliseconds()).Text()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	var tokenData TokenData
+	if err := json.Unmarshal([]byte(data), &tokenData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token data: %w", err)
+	}
+	return &tokenData, nil
 }
 
 // GetRefreshTokenData retrieves data associated with a refresh token
@@ -99,13 +310,146 @@ func (s *redisService) GetRefreshTokenData(ctx context.Context, tokenID string)
 
 // DeleteRefreshToken removes a refresh token
 func (s *redisService) DeleteRefreshToken(ctx context.Context, tokenID string) error {
-	key := RefreshTokenPrefix + tokenID
-	if err := s.client.Del(ctx, key).Err(); err != nil {
+	// Look up the owner to keep the per-user index in sync
+	data, err := s.GetRefreshTokenData(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, RefreshTokenPrefix+tokenID)
+	if data != nil {
+		pipe.ZRem(ctx, UserRefreshTokensPrefix+data.UserID, tokenID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to delete refresh token: %w", err)
 	}
 	return nil
 }
 
+// GetUserRefreshTokens returns the data of every stored refresh token of a
+// user, least recently issued first. Expired tokens are dropped from the
+// per-user index on the way.
+func (s *redisService) GetUserRefreshTokens(ctx context.Context, userID string) ([]TokenData, error) {
+	userKey := UserRefreshTokensPrefix + userID
+
+	tokenIDs, err := s.client.ZRange(ctx, userKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refresh tokens: %w", err)
+	}
+
+	if len(tokenIDs) == 0 {
+		return []TokenData{}, nil
+	}
+
+	// Fetch them all in one round trip
+	keys := make([]string, len(tokenIDs))
+	for i, tokenID := range tokenIDs {
+		keys[i] = RefreshTokenPrefix + tokenID
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token data: %w", err)
+	}
+
+	tokens := make([]TokenData, 0, len(tokenIDs))
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, tokenIDs[i])
+			continue
+		}
+
+		var tokenData TokenData
+		if err := json.Unmarshal([]byte(data), &tokenData); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal token data: %w", err)
+		}
+		tokens = append(tokens, tokenData)
+	}
+
+	if len(expired) > 0 {
+		s.client.ZRem(ctx, userKey, expired...)
+	}
+
+	return tokens, nil
+}
+
+// DeleteAllRefreshTokens removes every refresh token of a user and returns
+// how many were still stored
+func (s *redisService) DeleteAllRefreshTokens(ctx context.Context, userID string) (int, error) {
+	userKey := UserRefreshTokensPrefix + userID
+
+	tokenIDs, err := s.client.ZRange(ctx, userKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list refresh tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(tokenIDs)+1)
+	for _, tokenID := range tokenIDs {
+		keys = append(keys, RefreshTokenPrefix+tokenID)
+	}
+
+	// Count the tokens that hadn't expired yet, the index may still list expired ones
+	deleted := int64(0)
+	if len(keys) > 0 {
+		deleted, err = s.client.Del(ctx, keys...).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete refresh tokens: %w", err)
+		}
+	}
+
+	if err := s.client.Del(ctx, userKey).Err(); err != nil {
+		return int(deleted), fmt.Errorf("failed to delete refresh token index: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+// RevokeRefreshToken deletes a refresh token and blacklists the access token
+// issued with it, ending the session right away rather than when the access
+// token expires
+func (s *redisService) RevokeRefreshToken(ctx context.Context, data TokenData) error {
+	if err := s.DeleteRefreshToken(ctx, data.TokenID); err != nil {
+		return err
+	}
+	if data.AccessTokenID != "" {
+		if err := s.BlacklistToken(ctx, data.AccessTokenID, s.config.AccessExpiry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTokensRevokedBefore revokes every token of a user issued before cutoff,
+// kept to the millisecond so a login right after it isn't caught. It only
+// needs to outlive the longest token issued before it.
+func (s *redisService) SetTokensRevokedBefore(ctx context.Context, userID string, cutoff time.Time) error {
+	ttl := s.config.TokenExpiry
+	if s.config.AccessExpiry > ttl {
+		ttl = s.config.AccessExpiry
+	}
+
+	key := RevokedBeforePrefix + userID
+	if err := s.client.Set(ctx, key, cutoff.UnixMilli(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	return nil
+}
+
+// GetTokensRevokedBefore returns the revocation cutoff of a user, the zero
+// time if none is set
+func (s *redisService) GetTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	cutoff, err := s.client.Get(ctx, RevokedBeforePrefix+userID).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get token revocation cutoff: %w", err)
+	}
+	return time.UnixMilli(cutoff), nil
+}
+
 // BlacklistToken adds a token to the blacklist
 func (s *redisService) BlacklistToken(ctx context.Context, tokenID string, expiry time.Duration) error {
 	key := BlacklistPrefix + tokenID
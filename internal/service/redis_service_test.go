@@ -0,0 +1,243 @@
+// internal/service/redis_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisService(t *testing.T) (*redisService, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	s := NewRedisService(RedisServiceConfig{
+		Address:      server.Addr(),
+		TokenExpiry:  24 * time.Hour,
+		AccessExpiry: 15 * time.Minute,
+	}, nil).(*redisService)
+	t.Cleanup(func() { s.client.Close() })
+
+	return s, server
+}
+
+// testTokenData returns the data of a refresh token of user-1 in family-1
+func testTokenData(tokenID, accessTokenID string, issuedAt time.Time) TokenData {
+	return TokenData{
+		UserID:        "user-1",
+		TokenID:       tokenID,
+		UserRole:      "user",
+		IssuedAt:      issuedAt,
+		AccessTokenID: accessTokenID,
+		FamilyID:      "family-1",
+	}
+}
+
+func TestRedisServiceTokenFamilyRotation(t *testing.T) {
+	s, server := newTestRedisService(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	first := testTokenData("token-1", "access-1", now)
+	if err := s.StoreRefreshToken(ctx, first.TokenID, first); err != nil {
+		t.Fatalf("StoreRefreshToken() error = %v", err)
+	}
+	if current, _ := server.Get(TokenFamilyPrefix + "family-1"); current != "token-1" {
+		t.Errorf("current token of the family = %q, want token-1", current)
+	}
+
+	// Rotating consumes the old token once and remembers its family
+	consumed, err := s.ConsumeRefreshToken(ctx, first)
+	if err != nil || !consumed {
+		t.Fatalf("ConsumeRefreshToken() = %v, %v, want true, nil", consumed, err)
+	}
+	if consumed, _ := s.ConsumeRefreshToken(ctx, first); consumed {
+		t.Error("ConsumeRefreshToken() consumed the same token twice")
+	}
+	if familyID, err := s.GetRotatedTokenFamily(ctx, "token-1"); err != nil || familyID != "family-1" {
+		t.Errorf("GetRotatedTokenFamily() = %q, %v, want family-1", familyID, err)
+	}
+	if familyID, err := s.GetRotatedTokenFamily(ctx, "token-unused"); err != nil || familyID != "" {
+		t.Errorf("GetRotatedTokenFamily() of an unused token = %q, %v, want none", familyID, err)
+	}
+
+	second := testTokenData("token-2", "access-2", now.Add(time.Second))
+	if err := s.StoreRefreshToken(ctx, second.TokenID, second); err != nil {
+		t.Fatalf("StoreRefreshToken() error = %v", err)
+	}
+	if current, _ := server.Get(TokenFamilyPrefix + "family-1"); current != "token-2" {
+		t.Errorf("current token of the family = %q, want token-2", current)
+	}
+}
+
+func TestRedisServiceRevokeTokenFamily(t *testing.T) {
+	s, server := newTestRedisService(t)
+	ctx := context.Background()
+
+	data := testTokenData("token-2", "access-2", time.Now())
+	if err := s.StoreRefreshToken(ctx, data.TokenID, data); err != nil {
+		t.Fatalf("StoreRefreshToken() error = %v", err)
+	}
+
+	revoked, err := s.RevokeTokenFamily(ctx, "family-1")
+	if err != nil {
+		t.Fatalf("RevokeTokenFamily() error = %v", err)
+	}
+	if revoked == nil || revoked.TokenID != "token-2" {
+		t.Fatalf("RevokeTokenFamily() = %+v, want the data of token-2", revoked)
+	}
+
+	if server.Exists(RefreshTokenPrefix + "token-2") {
+		t.Error("the family's current refresh token was not deleted")
+	}
+	if ids, _ := server.ZMembers(UserRefreshTokensPrefix + "user-1"); len(ids) != 0 {
+		t.Errorf("user index still holds %v", ids)
+	}
+	if blacklisted, _ := s.IsTokenBlacklisted(ctx, "access-2"); !blacklisted {
+		t.Error("the access token of the revoked refresh token is not blacklisted")
+	}
+	if ttl := server.TTL(BlacklistPrefix + "access-2"); ttl != s.config.AccessExpiry {
+		t.Errorf("blacklist TTL = %v, want %v", ttl, s.config.AccessExpiry)
+	}
+	if tombstone, _ := server.Get(TokenFamilyPrefix + "family-1"); tombstone != revokedFamily {
+		t.Errorf("family = %q, want the %q tombstone", tombstone, revokedFamily)
+	}
+}
+
+func TestRedisServiceRevokedFamilyRefusesTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(ctx context.Context, s *redisService) error
+	}{
+		{
+			name: "family with a live token",
+			prepare: func(ctx context.Context, s *redisService) error {
+				data := testTokenData("token-1", "access-1", time.Now())
+				return s.StoreRefreshToken(ctx, data.TokenID, data)
+			},
+		},
+		{
+			// A rotation consumed the token and is about to store the next one
+			name: "family mid-rotation",
+			prepare: func(ctx context.Context, s *redisService) error {
+				data := testTokenData("token-1", "access-1", time.Now())
+				if err := s.StoreRefreshToken(ctx, data.TokenID, data); err != nil {
+					return err
+				}
+				_, err := s.ConsumeRefreshToken(ctx, data)
+				return err
+			},
+		},
+		{
+			name:    "unknown family",
+			prepare: func(ctx context.Context, s *redisService) error { return nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, server := newTestRedisService(t)
+			ctx := context.Background()
+
+			if err := tt.prepare(ctx, s); err != nil {
+				t.Fatalf("prepare error = %v", err)
+			}
+			if _, err := s.RevokeTokenFamily(ctx, "family-1"); err != nil {
+				t.Fatalf("RevokeTokenFamily() error = %v", err)
+			}
+
+			next := testTokenData("token-next", "access-next", time.Now())
+			if err := s.StoreRefreshToken(ctx, next.TokenID, next); !errors.Is(err, ErrTokenFamilyRevoked) {
+				t.Errorf("StoreRefreshToken() error = %v, want %v", err, ErrTokenFamilyRevoked)
+			}
+			if _, err := s.StoreRefreshTokenWithinLimit(ctx, next.TokenID, next, 5, true); !errors.Is(err, ErrTokenFamilyRevoked) {
+				t.Errorf("StoreRefreshTokenWithinLimit() error = %v, want %v", err, ErrTokenFamilyRevoked)
+			}
+			if server.Exists(RefreshTokenPrefix + "token-next") {
+				t.Error("a token was stored into the revoked family")
+			}
+		})
+	}
+}
+
+func TestRedisServiceTokensRevokedBefore(t *testing.T) {
+	s, server := newTestRedisService(t)
+	ctx := context.Background()
+
+	cutoff, err := s.GetTokensRevokedBefore(ctx, "user-1")
+	if err != nil || !cutoff.IsZero() {
+		t.Errorf("GetTokensRevokedBefore() without a cutoff = %v, %v, want the zero time", cutoff, err)
+	}
+
+	want := time.UnixMilli(1700000000123)
+	if err := s.SetTokensRevokedBefore(ctx, "user-1", want.Add(456*time.Microsecond)); err != nil {
+		t.Fatalf("SetTokensRevokedBefore() error = %v", err)
+	}
+	if stored, _ := server.Get(RevokedBeforePrefix + "user-1"); stored != "1700000000123" {
+		t.Errorf("stored cutoff = %q, want Unix milliseconds", stored)
+	}
+	if ttl := server.TTL(RevokedBeforePrefix + "user-1"); ttl != s.config.TokenExpiry {
+		t.Errorf("cutoff TTL = %v, want the longest token lifetime %v", ttl, s.config.TokenExpiry)
+	}
+
+	cutoff, err = s.GetTokensRevokedBefore(ctx, "user-1")
+	if err != nil || !cutoff.Equal(want) {
+		t.Errorf("GetTokensRevokedBefore() = %v, %v, want %v", cutoff, err, want)
+	}
+}
+
+func TestRedisServiceGetUserRefreshTokens(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      int
+		expired     []string // Token IDs whose data expired
+		wantTokens  []string
+		wantTracked int // Token IDs left in the user's set
+	}{
+		{name: "no sessions", wantTokens: []string{}},
+		{name: "live sessions", stored: 3, wantTokens: []string{"token-1", "token-2", "token-3"}, wantTracked: 3},
+		{name: "expired sessions dropped", stored: 3, expired: []string{"token-1", "token-3"}, wantTokens: []string{"token-2"}, wantTracked: 1},
+		{name: "every session expired", stored: 2, expired: []string{"token-1", "token-2"}, wantTokens: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, server := newTestRedisService(t)
+			ctx := context.Background()
+			issuedAt := time.Now()
+
+			for i := 1; i <= tt.stored; i++ {
+				data := testTokenData(fmt.Sprintf("token-%d", i), fmt.Sprintf("access-%d", i), issuedAt.Add(time.Duration(i)*time.Millisecond))
+				data.FamilyID = fmt.Sprintf("family-%d", i)
+				if err := s.StoreRefreshToken(ctx, data.TokenID, data); err != nil {
+					t.Fatalf("StoreRefreshToken() error = %v", err)
+				}
+			}
+			for _, tokenID := range tt.expired {
+				server.Del(RefreshTokenPrefix + tokenID)
+			}
+
+			tokens, err := s.GetUserRefreshTokens(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("GetUserRefreshTokens() error = %v", err)
+			}
+			got := make([]string, 0, len(tokens))
+			for _, token := range tokens {
+				got = append(got, token.TokenID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantTokens, ",") {
+				t.Errorf("GetUserRefreshTokens() = %v, want %v", got, tt.wantTokens)
+			}
+
+			tracked, _ := server.ZMembers(UserRefreshTokensPrefix + "user-1")
+			if len(tracked) != tt.wantTracked {
+				t.Errorf("user's set holds %v, want %d token IDs", tracked, tt.wantTracked)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// internal/service/refresh_binding.go
+package service
+
+import (
+	"net"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/useragent"
+)
+
+// What happens when a refresh token is used by a different client than the
+// one it was issued to
+const (
+	RefreshBindingIgnore = "ignore" // Don't compare
+	RefreshBindingLog    = "log"    // Log a security event and refresh anyway
+	RefreshBindingReject = "reject" // Log a security event and refuse the refresh
+)
+
+// RefreshBindingConfig binds refresh tokens to the client they were issued
+// to at login. Browser and OS updates and moves within a network are
+// expected, so only the user agent family and the IP subnet are compared.
+type RefreshBindingConfig struct {
+	Policy     string
+	IPv4Prefix int // Prefix length of the compared IPv4 subnet, e.g. 24
+	IPv6Prefix int // Prefix length of the compared IPv6 subnet, e.g. 64
+}
+
+// Refresh binding attributes that can change
+const (
+	BindingUserAgent = "user_agent"
+	BindingIPSubnet  = "ip_subnet"
+)
+
+// Mismatches compares the client a refresh token was issued to with the one
+// using it, and returns the attributes that changed. Attributes that weren't
+// recorded at login are not compared.
+func (c RefreshBindingConfig) Mismatches(issued TokenData, userAgent, clientIP string) []string {
+	if c.Policy == RefreshBindingIgnore {
+		return nil
+	}
+
+	var changed []string
+	if issued.UserAgent != "" &&
+		useragent.Parse(issued.UserAgent).Family() != useragent.Parse(userAgent).Family() {
+		changed = append(changed, BindingUserAgent)
+	}
+	if !c.sameSubnet(issued.ClientIP, clientIP) {
+		changed = append(changed, BindingIPSubnet)
+	}
+	return changed
+}
+
+// sameSubnet reports whether two IPs are in the same subnet. IPs that are
+// unknown can't be compared and count as the same.
+func (c RefreshBindingConfig) sameSubnet(issuedIP, clientIP string) bool {
+	issued, current := net.ParseIP(issuedIP), net.ParseIP(clientIP)
+	if issued == nil || current == nil {
+		return true
+	}
+
+	if issued4, current4 := issued.To4(), current.To4(); issued4 != nil || current4 != nil {
+		if issued4 == nil || current4 == nil {
+			return false
+		}
+		mask := net.CIDRMask(c.IPv4Prefix, 32)
+		return issued4.Mask(mask).Equal(current4.Mask(mask))
+	}
+
+	mask := net.CIDRMask(c.IPv6Prefix, 128)
+	return issued.Mask(mask).Equal(current.Mask(mask))
+}
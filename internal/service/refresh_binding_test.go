@@ -0,0 +1,65 @@
+// internal/service/refresh_binding_test.go
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRefreshBindingMismatches(t *testing.T) {
+	const (
+		chrome       = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36"
+		chromeUpdate = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+		firefox      = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+	)
+
+	config := RefreshBindingConfig{Policy: RefreshBindingReject, IPv4Prefix: 24, IPv6Prefix: 64}
+
+	tests := []struct {
+		name      string
+		config    RefreshBindingConfig
+		issuedUA  string
+		issuedIP  string
+		userAgent string
+		clientIP  string
+		want      []string
+	}{
+		{name: "same client", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chrome, clientIP: "203.0.113.7"},
+		{name: "browser updated", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chromeUpdate, clientIP: "203.0.113.7"},
+		{name: "same IPv4 subnet", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chrome, clientIP: "203.0.113.250"},
+		{name: "other IPv4 subnet", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chrome, clientIP: "203.0.114.7", want: []string{BindingIPSubnet}},
+		{name: "same IPv6 subnet", config: config, issuedUA: chrome, issuedIP: "2001:db8:1:2::1", userAgent: chrome, clientIP: "2001:db8:1:2:ffff::9"},
+		{name: "other IPv6 subnet", config: config, issuedUA: chrome, issuedIP: "2001:db8:1:2::1", userAgent: chrome, clientIP: "2001:db8:1:3::1", want: []string{BindingIPSubnet}},
+		{name: "IPv4 to IPv6", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chrome, clientIP: "2001:db8::1", want: []string{BindingIPSubnet}},
+		{name: "other browser", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: firefox, clientIP: "203.0.113.7", want: []string{BindingUserAgent}},
+		{name: "everything changed", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: firefox, clientIP: "198.51.100.4", want: []string{BindingUserAgent, BindingIPSubnet}},
+		{name: "nothing recorded at login", config: config, userAgent: firefox, clientIP: "198.51.100.4"},
+		{name: "unknown client IP", config: config, issuedUA: chrome, issuedIP: "203.0.113.7", userAgent: chrome, clientIP: "unknown"},
+		{
+			name:      "ignored",
+			config:    RefreshBindingConfig{Policy: RefreshBindingIgnore, IPv4Prefix: 24, IPv6Prefix: 64},
+			issuedUA:  chrome,
+			issuedIP:  "203.0.113.7",
+			userAgent: firefox,
+			clientIP:  "198.51.100.4",
+		},
+		{
+			name:      "exact IPv4 match",
+			config:    RefreshBindingConfig{Policy: RefreshBindingLog, IPv4Prefix: 32, IPv6Prefix: 128},
+			issuedUA:  chrome,
+			issuedIP:  "203.0.113.7",
+			userAgent: chrome,
+			clientIP:  "203.0.113.8",
+			want:      []string{BindingIPSubnet},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issued := TokenData{UserAgent: tt.issuedUA, ClientIP: tt.issuedIP}
+			if got := tt.config.Mismatches(issued, tt.userAgent, tt.clientIP); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mismatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,20 +6,34 @@ import (
 	"errors"
 	"fmt"
 	"html"
+	"math"
 	"strings"
 	"time"
-	"unicode"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// MaxPasswordChars caps password length, strength estimation and hashing
+// get costly on long input. bcrypt only takes the first 72 bytes.
+const (
+	MaxPasswordChars       = 128
+	maxBcryptPasswordBytes = 72
 )
 
+// ErrRevocationCheckUnavailable is returned by ValidateJWT when it can't tell
+// whether a token was revoked, e.g. because Redis is down
+var ErrRevocationCheckUnavailable = errors.New("token revocation check unavailable")
+
 // Update SecurityConfig struct to include JWT settings
 type SecurityConfig struct {
-	BcryptCost       int
+	PasswordHasher   PasswordHasherConfig
 	MinPasswordChars int
-	JWTSecret        string
+	MinPasswordScore int         // Minimum estimated strength, 0-4
+	KeyRing          *JWTKeyRing // Signs and verifies access and refresh tokens
 	TokenExpiry      time.Duration
 	RefreshExpiry    time.Duration
 	Issuer           string
@@ -27,19 +41,29 @@ type SecurityConfig struct {
 
 // Implementation of the SecurityService interface
 type securityService struct {
-	config       SecurityConfig
-	redisService RedisService
+	config        SecurityConfig
+	hasher        PasswordHasher
+	redisService  RedisService
+	breachChecker BreachedPasswordChecker
 }
 
-func NewSecurityService(config SecurityConfig, redisService RedisService) (SecurityService, error) {
-	// Validate JWT secret length (at least 32 characters)
-	if len(config.JWTSecret) < 32 {
-		return nil, fmt.Errorf("JWT secret is too short, must be at least 32 characters")
+// NewSecurityService creates the security service, breachChecker is optional
+// and rejects passwords found in a breached-password corpus
+func NewSecurityService(config SecurityConfig, redisService RedisService, breachChecker BreachedPasswordChecker) (SecurityService, error) {
+	if config.KeyRing == nil {
+		return nil, fmt.Errorf("JWT key ring is required")
+	}
+
+	hasher, err := NewPasswordHasher(config.PasswordHasher)
+	if err != nil {
+		return nil, err
 	}
 
 	return &securityService{
-		config:       config,
-		redisService: redisService,
+		config:        config,
+		hasher:        hasher,
+		redisService:  redisService,
+		breachChecker: breachChecker,
 	}, nil
 }
 
@@ -52,64 +76,60 @@ func (s *securityService) SanitizeInput(ctx context.Context, input string) strin
 	return sanitized
 }
 
-// ValidatePassword checks if a password meets security requirements
-func (s *securityService) ValidatePassword(ctx context.Context, password string) (bool, string) {
-	if len(password) < s.config.MinPasswordChars {
-		return false, "Password must be at least " + string(rune(s.config.MinPasswordChars)) + " characters long"
+// ValidatePassword checks a password's length in characters, estimated
+// strength and, when configured, the breached-password corpus. userInputs
+// are the user's own details (email, phone), passwords built from them score
+// lower.
+func (s *securityService) ValidatePassword(ctx context.Context, password string, userInputs ...string) PasswordCheck {
+	length := utf8.RuneCountInString(password)
+	if length < s.config.MinPasswordChars {
+		return PasswordCheck{Reason: i18n.T(ctx, "password.too_short", s.config.MinPasswordChars)}
+	}
+	if length > MaxPasswordChars {
+		return PasswordCheck{Reason: i18n.T(ctx, "password.too_long", MaxPasswordChars)}
+	}
+	if s.config.PasswordHasher.Algorithm == PasswordHashBcrypt && len(password) > maxBcryptPasswordBytes {
+		return PasswordCheck{Reason: i18n.T(ctx, "password.too_long_bytes", maxBcryptPasswordBytes)}
 	}
 
-	var (
-		hasUpper   bool
-		hasLower   bool
-		hasNumber  bool
-		hasSpecial bool
-	)
-
-	for _, char := range password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsDigit(char):
-			hasNumber = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
+	score, suggestions := estimatePasswordStrength(ctx, password, userInputs)
+	if score < s.config.MinPasswordScore {
+		return PasswordCheck{
+			Reason:      i18n.T(ctx, "password.too_weak"),
+			Score:       score,
+			Suggestions: suggestions,
 		}
 	}
 
-	if !hasUpper {
-		return false, "Password must contain at least one uppercase letter"
-	}
-	if !hasLower {
-		return false, "Password must contain at least one lowercase letter"
-	}
-	if !hasNumber {
-		return false, "Password must contain at least one number"
-	}
-	if !hasSpecial {
-		return false, "Password must contain at least one special character"
+	// A failed lookup doesn't block the user, the checker logs it
+	if s.breachChecker != nil {
+		if breached, err := s.breachChecker.IsBreached(ctx, password); err == nil && breached {
+			return PasswordCheck{Reason: i18n.T(ctx, "password.breached"), Score: score}
+		}
 	}
 
-	return true, ""
+	return PasswordCheck{Valid: true, Score: score}
 }
 
-// HashPassword hashes a password securely
+// HashPassword hashes a password with the configured algorithm
 func (s *securityService) HashPassword(ctx context.Context, password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), s.config.BcryptCost)
-	if err != nil {
-		return "", err
-	}
-	return string(bytes), nil
+	return s.hasher.Hash(password)
 }
 
-// VerifyPassword checks if a password matches its hash
+// VerifyPassword checks if a password matches its hash, whichever supported
+// algorithm the hash was made with
 func (s *securityService) VerifyPassword(ctx context.Context, hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+	ok, err := s.hasher.Verify(hashedPassword, password)
+	return err == nil && ok
+}
+
+// PasswordNeedsRehash reports whether a hash was made with an older algorithm or parameters
+func (s *securityService) PasswordNeedsRehash(ctx context.Context, hashedPassword string) bool {
+	return s.hasher.NeedsRehash(hashedPassword)
 }
 
-func (s *securityService) GenerateJWT(ctx context.Context, userID, role string, lastLogin time.Time) (string, error) {
+// GenerateJWT generates an access token and returns it with its token ID
+func (s *securityService) GenerateJWT(ctx context.Context, userID, role string, lastLogin time.Time) (string, string, error) {
 	// Create token ID
 	tokenID := uuid.New().String()
 
@@ -121,25 +141,26 @@ func (s *securityService) GenerateJWT(ctx context.Context, userID, role string,
 		"sub":        userID,                                      // Subject (user ID)
 		"roles":      roles,                                       // User roles as array
 		"iss":        s.config.Issuer,                             // Issuer
-		"iat":        time.Now().Unix(),                           // Issued at
+		"iat":        issuedAtClaim(time.Now()),                   // Issued at, with milliseconds
 		"exp":        time.Now().Add(s.config.TokenExpiry).Unix(), // Expiry
 		"jti":        tokenID,                                     // JWT ID
 		"last_login": lastLogin.Unix(),                            // Last login timestamp
 	}
 
 	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signingKey := s.config.KeyRing.SigningKey()
+	token := jwt.NewWithClaims(signingKey.Method, claims)
 
 	// Set header values for better security
-	token.Header["kid"] = "auth-key-1" // Key ID for future key rotation
+	token.Header["kid"] = signingKey.KeyID // Key ID for key rotation
 
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	// Sign the token with the private key (or shared secret for HS256)
+	tokenString, err := token.SignedString(signingKey.SignKey)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return tokenString, nil
+	return tokenString, tokenID, nil
 }
 
 // GenerateRefreshToken generates a refresh token for the authenticated user
@@ -151,20 +172,21 @@ func (s *securityService) GenerateRefreshToken(ctx context.Context, userID strin
 	claims := jwt.MapClaims{
 		"sub": userID,                                        // Subject (user ID)
 		"iss": s.config.Issuer,                               // Issuer
-		"iat": time.Now().Unix(),                             // Issued at
+		"iat": issuedAtClaim(time.Now()),                     // Issued at, with milliseconds
 		"exp": time.Now().Add(s.config.RefreshExpiry).Unix(), // Expiry
 		"jti": tokenID,                                       // JWT ID
 		"typ": "refresh",                                     // Token type
 	}
 
-	// Create the token with explicit HS256 method
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Create the token
+	signingKey := s.config.KeyRing.SigningKey()
+	token := jwt.NewWithClaims(signingKey.Method, claims)
 
 	// Set header values for better security
-	token.Header["kid"] = "refresh-key-1" // Key ID for future key rotation
+	token.Header["kid"] = signingKey.KeyID // Key ID for key rotation
 
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	// Sign the token with the private key (or shared secret for HS256)
+	tokenString, err := token.SignedString(signingKey.SignKey)
 	if err != nil {
 		return "", "", err
 	}
@@ -172,14 +194,26 @@ func (s *securityService) GenerateRefreshToken(ctx context.Context, userID strin
 	return tokenString, tokenID, nil
 }
 
+// parseToken parses a token signed with one of the key ring's keys, picked
+// by the kid header. Only that key's algorithm is accepted so a token can't
+// pick a weaker one for itself.
+func (s *securityService) parseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := s.config.KeyRing.VerificationKey(keyID)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", keyID)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.VerifyKey, nil
+	}, jwt.WithValidMethods(s.config.KeyRing.Algorithms()))
+}
+
 func (s *securityService) ExtractTokenID(ctx context.Context, tokenString string) (string, error) {
 	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := s.parseToken(tokenString)
 
 	if err != nil {
 		return "", err
@@ -201,13 +235,7 @@ func (s *securityService) ExtractTokenID(ctx context.Context, tokenString string
 // ValidateJWT validates the JWT token and returns the claims
 func (s *securityService) ValidateJWT(ctx context.Context, tokenString string) (map[string]interface{}, error) {
 	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := s.parseToken(tokenString)
 
 	if err != nil {
 		// Check for specific error types
@@ -251,13 +279,25 @@ func (s *securityService) ValidateJWT(ctx context.Context, tokenString string) (
 
 	isBlacklisted, err := s.redisService.IsTokenBlacklisted(ctx, tokenID)
 	if err != nil {
-		return nil, fmt.Errorf("error checking blacklist: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrRevocationCheckUnavailable, err)
 	}
 
 	if isBlacklisted {
 		return nil, errors.New("token is blacklisted")
 	}
 
+	// Check if every token of the user was revoked after this one was issued
+	if userID, ok := claims["sub"].(string); ok {
+		cutoff, err := s.redisService.GetTokensRevokedBefore(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRevocationCheckUnavailable, err)
+		}
+		iat, _ := claims["iat"].(float64)
+		if !cutoff.IsZero() && issuedAtTime(iat).Before(cutoff) {
+			return nil, errors.New("token is blacklisted")
+		}
+	}
+
 	// Convert to map
 	claimsMap := make(map[string]interface{})
 	for key, value := range claims {
@@ -266,3 +306,15 @@ func (s *securityService) ValidateJWT(ctx context.Context, tokenString string) (
 
 	return claimsMap, nil
 }
+
+// issuedAtClaim returns the iat claim of a token issued at t. RFC 7519 allows
+// fractional NumericDates; milliseconds tell a token issued right after a
+// logout from all devices from one issued right before it.
+func issuedAtClaim(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+// issuedAtTime converts an iat claim back to a time, to the millisecond
+func issuedAtTime(iat float64) time.Time {
+	return time.UnixMilli(int64(math.Round(iat * 1000)))
+}
@@ -0,0 +1,187 @@
+// internal/service/security_service_test.go
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/i18n"
+)
+
+// testHMACKeyRing returns a key ring with a single HS256 key
+func testHMACKeyRing(t *testing.T) *JWTKeyRing {
+	t.Helper()
+
+	signingKey, err := NewHMACSigningKey("test-key", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("NewHMACSigningKey() error = %v", err)
+	}
+	keyRing, err := NewJWTKeyRing(signingKey)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing() error = %v", err)
+	}
+	return keyRing
+}
+
+func newTestSecurityService(t *testing.T, keyRing *JWTKeyRing, redisService RedisService) *securityService {
+	t.Helper()
+
+	s, err := NewSecurityService(SecurityConfig{
+		PasswordHasher: PasswordHasherConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost},
+		KeyRing:        keyRing,
+		TokenExpiry:    15 * time.Minute,
+		RefreshExpiry:  24 * time.Hour,
+		Issuer:         "test",
+	}, redisService, nil)
+	if err != nil {
+		t.Fatalf("NewSecurityService() error = %v", err)
+	}
+	return s.(*securityService)
+}
+
+func TestIssuedAtClaimKeepsMilliseconds(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "whole second", t: time.UnixMilli(1700000000000), want: time.UnixMilli(1700000000000)},
+		{name: "milliseconds", t: time.UnixMilli(1700000000123), want: time.UnixMilli(1700000000123)},
+		{name: "last millisecond", t: time.UnixMilli(1700000000999), want: time.UnixMilli(1700000000999)},
+		{name: "sub-millisecond is dropped", t: time.UnixMilli(1700000000123).Add(999 * time.Microsecond), want: time.UnixMilli(1700000000123)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issuedAtTime(issuedAtClaim(tt.t)); !got.Equal(tt.want) {
+				t.Errorf("issuedAtTime(issuedAtClaim(%v)) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityServiceValidateJWTRevocationCutoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		cutoffShift time.Duration // Cutoff relative to the token's iat
+		wantRevoked bool
+	}{
+		{name: "revoked a millisecond after issue", cutoffShift: time.Millisecond, wantRevoked: true},
+		{name: "revoked a second after issue", cutoffShift: time.Second, wantRevoked: true},
+		{name: "issued at the cutoff", cutoffShift: 0},
+		{name: "issued a millisecond after the cutoff", cutoffShift: -time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisService, _ := newTestRedisService(t)
+			s := newTestSecurityService(t, testHMACKeyRing(t), redisService)
+			ctx := context.Background()
+
+			token, _, err := s.GenerateJWT(ctx, "user-1", "user", time.Now())
+			if err != nil {
+				t.Fatalf("GenerateJWT() error = %v", err)
+			}
+			claims, err := s.ValidateJWT(ctx, token)
+			if err != nil {
+				t.Fatalf("ValidateJWT() before revocation error = %v", err)
+			}
+			issuedAt := issuedAtTime(claims["iat"].(float64))
+
+			if err := redisService.SetTokensRevokedBefore(ctx, "user-1", issuedAt.Add(tt.cutoffShift)); err != nil {
+				t.Fatalf("SetTokensRevokedBefore() error = %v", err)
+			}
+
+			_, err = s.ValidateJWT(ctx, token)
+			if tt.wantRevoked && (err == nil || err.Error() != "token is blacklisted") {
+				t.Errorf("ValidateJWT() error = %v, want the token revoked", err)
+			}
+			if !tt.wantRevoked && err != nil {
+				t.Errorf("ValidateJWT() error = %v, want the token accepted", err)
+			}
+		})
+	}
+}
+
+// breachedPasswords is a BreachedPasswordChecker over a fixed list
+type breachedPasswords []string
+
+func (b breachedPasswords) IsBreached(ctx context.Context, password string) (bool, error) {
+	for _, breached := range b {
+		if breached == password {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestSecurityServiceValidatePassword(t *testing.T) {
+	userInputs := []string{"amal@example.com", "+919876543210"}
+
+	tests := []struct {
+		name           string
+		algorithm      string
+		password       string
+		wantReason     string // Message ID, empty if the password is accepted
+		wantReasonArg  interface{}
+		wantSuggestion string // Message ID of a suggestion that must be given
+	}{
+		{name: "strong passphrase", password: "purple monkey dishwasher lamp"},
+		{name: "too short", password: "Xq9#vL2", wantReason: "password.too_short", wantReasonArg: 8},
+		{name: "too many characters", password: strings.Repeat("purple monkey ", 10), wantReason: "password.too_long", wantReasonArg: MaxPasswordChars},
+		{name: "common password", password: "password123", wantReason: "password.too_weak", wantSuggestion: "password.suggestion.common_words"},
+		{name: "keyboard pattern", password: "qwertyuiop12", wantReason: "password.too_weak", wantSuggestion: "password.suggestion.longer"},
+		{name: "built from the email", password: "amal.example2024", wantReason: "password.too_weak", wantSuggestion: "password.suggestion.personal_info"},
+		{name: "built from the phone number", password: "amal9876543210", wantReason: "password.too_weak", wantSuggestion: "password.suggestion.personal_info"},
+		{name: "breached", password: "correct horse battery staple", wantReason: "password.breached"},
+		// 30 Malayalam characters take 90 bytes, more than bcrypt reads
+		{name: "too many bytes for bcrypt", algorithm: PasswordHashBcrypt, password: strings.Repeat("കഖഗ", 10), wantReason: "password.too_long_bytes", wantReasonArg: maxBcryptPasswordBytes},
+		{name: "no byte limit for argon2id", algorithm: PasswordHashArgon2id, password: "ആന പച്ച മഴ കടൽ പുസ്തകം നക്ഷത്രം വീട് ചന്ദ്രൻ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := testBcryptConfig
+			if tt.algorithm == PasswordHashArgon2id {
+				hasher = testArgon2Config
+			}
+			s, err := NewSecurityService(SecurityConfig{
+				PasswordHasher:   hasher,
+				MinPasswordChars: 8,
+				MinPasswordScore: 3,
+				KeyRing:          testHMACKeyRing(t),
+			}, nil, breachedPasswords{"correct horse battery staple"})
+			if err != nil {
+				t.Fatalf("NewSecurityService() error = %v", err)
+			}
+
+			got := s.ValidatePassword(context.Background(), tt.password, userInputs...)
+			if got.Valid != (tt.wantReason == "") {
+				t.Fatalf("ValidatePassword() = %+v, want valid %v", got, tt.wantReason == "")
+			}
+			if tt.wantReason != "" {
+				var args []interface{}
+				if tt.wantReasonArg != nil {
+					args = append(args, tt.wantReasonArg)
+				}
+				if want := i18n.Translate(i18n.DefaultLocale, tt.wantReason, args...); got.Reason != want {
+					t.Errorf("Reason = %q, want %q", got.Reason, want)
+				}
+			}
+			if tt.wantSuggestion != "" {
+				want := i18n.Translate(i18n.DefaultLocale, tt.wantSuggestion)
+				found := false
+				for _, suggestion := range got.Suggestions {
+					found = found || suggestion == want
+				}
+				if !found {
+					t.Errorf("Suggestions = %q, want %q among them", got.Suggestions, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// internal/service/session_limiter.go
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// What happens on a login that would exceed the session limit
+const (
+	SessionLimitPolicyEvict  = "evict"  // Sign out the least recently used session
+	SessionLimitPolicyReject = "reject" // Refuse the new login
+)
+
+// AnyRole is the session limit key that applies to roles without their own limit
+const AnyRole = "*"
+
+// SessionLimitConfig caps the number of concurrent sessions per role. Roles
+// without a limit, and without an AnyRole fallback, are unlimited.
+type SessionLimitConfig struct {
+	Limits map[string]int
+	Policy string
+}
+
+type sessionLimiter struct {
+	config       SessionLimitConfig
+	redisService RedisService
+	logger       *logger.Logger
+}
+
+// NewSessionLimiter creates a session limiter
+func NewSessionLimiter(config SessionLimitConfig, redisService RedisService, logger *logger.Logger) (SessionLimiter, error) {
+	switch config.Policy {
+	case SessionLimitPolicyEvict, SessionLimitPolicyReject:
+	default:
+		return nil, fmt.Errorf("unknown session limit policy %q", config.Policy)
+	}
+
+	return &sessionLimiter{
+		config:       config,
+		redisService: redisService,
+		logger:       logger,
+	}, nil
+}
+
+// Limit returns the session limit of a role, 0 if unlimited
+func (l *sessionLimiter) Limit(role string) int {
+	if limit, ok := l.config.Limits[role]; ok {
+		return limit
+	}
+	return l.config.Limits[AnyRole]
+}
+
+// StoreSession stores the refresh token of a new session. If the user is at
+// the role's limit, the evict policy signs out the least recently used
+// sessions, blacklisting their access tokens so they fail on their next
+// request, and returns them; the reject policy returns ErrSessionLimitReached
+// and stores nothing. Counting and storing happen in one Redis step, so
+// concurrent logins can't exceed the limit.
+func (l *sessionLimiter) StoreSession(ctx context.Context, tokenID string, data TokenData) ([]TokenData, error) {
+	limit := l.Limit(data.UserRole)
+	if limit <= 0 {
+		return nil, l.redisService.StoreRefreshToken(ctx, tokenID, data)
+	}
+
+	// A session is used every time its refresh token is rotated, which
+	// moves it to the end of the user's index
+	evicted, err := l.redisService.StoreRefreshTokenWithinLimit(ctx, tokenID, data, limit,
+		l.config.Policy == SessionLimitPolicyEvict)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range evicted {
+		l.logger.Info("Session evicted by session limit",
+			l.logger.Field("user_id", data.UserID),
+			l.logger.Field("session_id", session.SessionID()),
+			l.logger.Field("role", data.UserRole),
+			l.logger.Field("limit", limit))
+	}
+
+	return evicted, nil
+}
@@ -0,0 +1,211 @@
+// internal/service/session_limiter_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+	"go.uber.org/zap"
+)
+
+func newTestSessionLimiter(t *testing.T, redisService RedisService, policy string) SessionLimiter {
+	t.Helper()
+
+	limiter, err := NewSessionLimiter(SessionLimitConfig{
+		Limits: map[string]int{"user": 2}, // Roles without a limit are unlimited
+		Policy: policy,
+	}, redisService, &logger.Logger{Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("NewSessionLimiter() error = %v", err)
+	}
+	return limiter
+}
+
+// testSession returns the refresh token data of the n-th login of user-1,
+// every login starts a family of its own
+func testSession(n int, role string, issuedAt time.Time) TokenData {
+	return TokenData{
+		UserID:        "user-1",
+		TokenID:       fmt.Sprintf("token-%d", n),
+		UserRole:      role,
+		IssuedAt:      issuedAt,
+		AccessTokenID: fmt.Sprintf("access-%d", n),
+		FamilyID:      fmt.Sprintf("family-%d", n),
+	}
+}
+
+func TestSessionLimiterStoreSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		role        string
+		logins      int
+		wantErr     error // Of the last login
+		wantEvicted []string
+		wantStored  []string
+	}{
+		{
+			name:       "under the limit",
+			policy:     SessionLimitPolicyReject,
+			role:       "user",
+			logins:     2,
+			wantStored: []string{"token-1", "token-2"},
+		},
+		{
+			name:       "reject at the limit",
+			policy:     SessionLimitPolicyReject,
+			role:       "user",
+			logins:     3,
+			wantErr:    ErrSessionLimitReached,
+			wantStored: []string{"token-1", "token-2"},
+		},
+		{
+			name:        "evict the least recently issued",
+			policy:      SessionLimitPolicyEvict,
+			role:        "user",
+			logins:      3,
+			wantEvicted: []string{"token-1"},
+			wantStored:  []string{"token-2", "token-3"},
+		},
+		{
+			name:       "unlimited role",
+			policy:     SessionLimitPolicyReject,
+			role:       "admin",
+			logins:     4,
+			wantStored: []string{"token-1", "token-2", "token-3", "token-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisService, _ := newTestRedisService(t)
+			limiter := newTestSessionLimiter(t, redisService, tt.policy)
+			ctx := context.Background()
+			start := time.Now()
+
+			var (
+				evicted []TokenData
+				err     error
+			)
+			for i := 1; i <= tt.logins; i++ {
+				data := testSession(i, tt.role, start.Add(time.Duration(i)*time.Second))
+				evicted, err = limiter.StoreSession(ctx, data.TokenID, data)
+				if i < tt.logins && err != nil {
+					t.Fatalf("StoreSession() #%d error = %v", i, err)
+				}
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("StoreSession() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(evicted) != len(tt.wantEvicted) {
+				t.Fatalf("evicted %d sessions, want %v", len(evicted), tt.wantEvicted)
+			}
+			for i, session := range evicted {
+				if session.TokenID != tt.wantEvicted[i] {
+					t.Errorf("evicted %s, want %s", session.TokenID, tt.wantEvicted[i])
+				}
+				if blacklisted, _ := redisService.IsTokenBlacklisted(ctx, session.AccessTokenID); !blacklisted {
+					t.Errorf("access token of evicted session %s is not blacklisted", session.TokenID)
+				}
+				if data, _ := redisService.GetRefreshTokenData(ctx, session.TokenID); data != nil {
+					t.Errorf("refresh token of evicted session %s is still stored", session.TokenID)
+				}
+			}
+
+			stored, err := redisService.GetUserRefreshTokens(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("GetUserRefreshTokens() error = %v", err)
+			}
+			if len(stored) != len(tt.wantStored) {
+				t.Fatalf("stored %d sessions, want %v", len(stored), tt.wantStored)
+			}
+			for i, session := range stored {
+				if session.TokenID != tt.wantStored[i] {
+					t.Errorf("stored session #%d = %s, want %s", i+1, session.TokenID, tt.wantStored[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSessionLimiterIgnoresExpiredSessions(t *testing.T) {
+	redisService, server := newTestRedisService(t)
+	limiter := newTestSessionLimiter(t, redisService, SessionLimitPolicyReject)
+	ctx := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		data := testSession(i, "user", time.Now())
+		if _, err := limiter.StoreSession(ctx, data.TokenID, data); err != nil {
+			t.Fatalf("StoreSession() #%d error = %v", i, err)
+		}
+	}
+
+	// The index outlives a token that expired on its own
+	server.Del(RefreshTokenPrefix + "token-1")
+
+	data := testSession(3, "user", time.Now())
+	if _, err := limiter.StoreSession(ctx, data.TokenID, data); err != nil {
+		t.Errorf("StoreSession() error = %v, want the expired session not to count", err)
+	}
+}
+
+func TestSessionLimiterConcurrentLogins(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		wantStored int
+		wantErrs   int
+	}{
+		{name: "reject", policy: SessionLimitPolicyReject, wantStored: 2, wantErrs: 8},
+		{name: "evict", policy: SessionLimitPolicyEvict, wantStored: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisService, _ := newTestRedisService(t)
+			limiter := newTestSessionLimiter(t, redisService, tt.policy)
+			ctx := context.Background()
+
+			const logins = 10
+			var (
+				wg   sync.WaitGroup
+				mu   sync.Mutex
+				errs int
+			)
+			for i := 1; i <= logins; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					data := testSession(i, "user", time.Now())
+					_, err := limiter.StoreSession(ctx, data.TokenID, data)
+					if err != nil && !errors.Is(err, ErrSessionLimitReached) {
+						t.Errorf("StoreSession() error = %v", err)
+					}
+					if err != nil {
+						mu.Lock()
+						errs++
+						mu.Unlock()
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			stored, err := redisService.GetUserRefreshTokens(ctx, "user-1")
+			if err != nil {
+				t.Fatalf("GetUserRefreshTokens() error = %v", err)
+			}
+			if len(stored) != tt.wantStored {
+				t.Errorf("%d sessions stored, want %d", len(stored), tt.wantStored)
+			}
+			if errs != tt.wantErrs {
+				t.Errorf("%d logins rejected, want %d", errs, tt.wantErrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// internal/service/sms_gateway.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPSMSConfig holds HTTP SMS gateway configuration
+type HTTPSMSConfig struct {
+	URL      string // Endpoint messages are POSTed to
+	APIKey   string // Sent as a bearer token when set
+	SenderID string // Sender name or number shown to the recipient
+	Timeout  time.Duration
+}
+
+// Implementation of the SMSService interface backed by an HTTP gateway
+type httpSMSService struct {
+	config HTTPSMSConfig
+	client *http.Client
+}
+
+// gatewayRequest is the JSON body posted to the gateway
+type gatewayRequest struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+// NewHTTPSMSService creates an SMS service that posts messages as JSON
+// ({"from", "to", "message"}) to an HTTP gateway. Any 2xx reply counts as sent.
+func NewHTTPSMSService(config HTTPSMSConfig) (SMSService, error) {
+	if config.URL == "" {
+		return nil, errors.New("SMS gateway URL is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &httpSMSService{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// SendSMS posts the message to the gateway
+func (s *httpSMSService) SendSMS(ctx context.Context, to string, message string) error {
+	body, err := json.Marshal(gatewayRequest{
+		From:    s.config.SenderID,
+		To:      to,
+		Message: message,
+	})
+	if err != nil {
+		return &SMSDeliveryError{Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return &SMSDeliveryError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &SMSDeliveryError{Temporary: true, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// Drain so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	// Keep a bit of the reply for the logs, gateways explain rejections there
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &SMSDeliveryError{
+		StatusCode: resp.StatusCode,
+		Temporary:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		Err:        fmt.Errorf("gateway replied %q", bytes.TrimSpace(detail)),
+	}
+}
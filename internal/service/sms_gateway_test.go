@@ -0,0 +1,96 @@
+// internal/service/sms_gateway_test.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSMSServiceSendSMS(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		apiKey        string
+		wantErr       bool
+		wantTemporary bool
+	}{
+		{name: "accepted", status: http.StatusAccepted, apiKey: "sms-key"},
+		{name: "without an API key", status: http.StatusOK},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantErr: true, wantTemporary: true},
+		{name: "gateway down", status: http.StatusBadGateway, wantErr: true, wantTemporary: true},
+		{name: "rejected number", status: http.StatusBadRequest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got           gatewayRequest
+				authorization string
+			)
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("gateway got an invalid body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("gateway says no\n"))
+			}))
+			defer gateway.Close()
+
+			s, err := NewHTTPSMSService(HTTPSMSConfig{URL: gateway.URL, APIKey: tt.apiKey, SenderID: "QUBOOL"})
+			if err != nil {
+				t.Fatalf("NewHTTPSMSService() error = %v", err)
+			}
+
+			err = s.SendSMS(context.Background(), "+919876543210", "Your code is 123456")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendSMS() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var deliveryErr *SMSDeliveryError
+				if !errors.As(err, &deliveryErr) || !errors.Is(err, ErrSMSDelivery) {
+					t.Fatalf("SendSMS() error = %v, want an *SMSDeliveryError", err)
+				}
+				if deliveryErr.StatusCode != tt.status || deliveryErr.Temporary != tt.wantTemporary {
+					t.Errorf("SendSMS() error = %+v, want status %d temporary %v", deliveryErr, tt.status, tt.wantTemporary)
+				}
+			}
+
+			want := gatewayRequest{From: "QUBOOL", To: "+919876543210", Message: "Your code is 123456"}
+			if got != want {
+				t.Errorf("gateway got %+v, want %+v", got, want)
+			}
+			wantAuthorization := ""
+			if tt.apiKey != "" {
+				wantAuthorization = "Bearer " + tt.apiKey
+			}
+			if authorization != wantAuthorization {
+				t.Errorf("Authorization = %q, want %q", authorization, wantAuthorization)
+			}
+		})
+	}
+}
+
+func TestHTTPSMSServiceUnreachable(t *testing.T) {
+	gateway := httptest.NewServer(http.NotFoundHandler())
+	gateway.Close()
+
+	s, err := NewHTTPSMSService(HTTPSMSConfig{URL: gateway.URL})
+	if err != nil {
+		t.Fatalf("NewHTTPSMSService() error = %v", err)
+	}
+
+	var deliveryErr *SMSDeliveryError
+	err = s.SendSMS(context.Background(), "+919876543210", "Your code is 123456")
+	if !errors.As(err, &deliveryErr) || !deliveryErr.Temporary || deliveryErr.StatusCode != 0 {
+		t.Errorf("SendSMS() error = %v, want a temporary error without a status", err)
+	}
+
+	if _, err := NewHTTPSMSService(HTTPSMSConfig{}); err == nil {
+		t.Error("NewHTTPSMSService() without a URL error = nil, want an error")
+	}
+}
@@ -0,0 +1,103 @@
+// internal/service/sms_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+// SMS providers
+const (
+	SMSProviderConsole = "console" // Logs messages and optionally appends them to a file, for development
+	SMSProviderHTTP    = "http"    // Posts messages to an HTTP SMS gateway
+)
+
+// ErrSMSDelivery is the base error for failed SMS deliveries
+var ErrSMSDelivery = errors.New("SMS delivery failed")
+
+// SMSDeliveryError describes a failed SMS delivery
+type SMSDeliveryError struct {
+	StatusCode int  // Gateway HTTP status, 0 if the request never got a response
+	Temporary  bool // Retrying later could succeed
+	Err        error
+}
+
+func (e *SMSDeliveryError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("SMS delivery failed with status %d: %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("SMS delivery failed: %v", e.Err)
+}
+
+func (e *SMSDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SMSDeliveryError) Is(target error) bool {
+	return target == ErrSMSDelivery
+}
+
+// Implementation of the SMSService interface that only logs messages
+type consoleSMSService struct {
+	filePath string
+	logger   *logger.Logger
+	mu       sync.Mutex
+}
+
+// NewConsoleSMSService creates an SMS service stand-in that logs every
+// message and, when filePath is set, appends it there as a JSON line
+func NewConsoleSMSService(filePath string, logger *logger.Logger) (SMSService, error) {
+	if filePath != "" {
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create SMS file directory: %w", err)
+		}
+	}
+
+	return &consoleSMSService{
+		filePath: filePath,
+		logger:   logger,
+	}, nil
+}
+
+// SendSMS logs the message instead of sending it
+func (s *consoleSMSService) SendSMS(ctx context.Context, to string, message string) error {
+	s.logger.Info("SMS (console provider)",
+		s.logger.Field("to", to),
+		s.logger.Field("message", message))
+
+	if s.filePath == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(map[string]interface{}{
+		"to":      to,
+		"message": message,
+		"sent_at": time.Now().UTC(),
+	})
+	if err != nil {
+		return &SMSDeliveryError{Err: err}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return &SMSDeliveryError{Temporary: true, Err: err}
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return &SMSDeliveryError{Temporary: true, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// internal/service/sms_service_test.go
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/mohamedfawas/qubool-kallyanam/auth-service-qubool-kallyaanam/internal/util/logger"
+)
+
+func TestConsoleSMSServiceFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "sms", "outbox.jsonl")
+	s, err := NewConsoleSMSService(filePath, &logger.Logger{Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatalf("NewConsoleSMSService() error = %v", err)
+	}
+
+	sends := []struct{ to, message string }{
+		{to: "+919876543210", message: "Your code is 123456"},
+		{to: "+971501234567", message: "Your code is 654321"},
+	}
+	for _, send := range sends {
+		if err := s.SendSMS(context.Background(), send.to, send.message); err != nil {
+			t.Fatalf("SendSMS() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+
+	var lines []struct{ to, message string }
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line struct {
+			To      string `json:"to"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %d is not JSON: %v", len(lines)+1, err)
+		}
+		lines = append(lines, struct{ to, message string }{line.To, line.Message})
+	}
+
+	if len(lines) != len(sends) {
+		t.Fatalf("file holds %d messages, want %d", len(lines), len(sends))
+	}
+	for i := range sends {
+		if lines[i] != sends[i] {
+			t.Errorf("line %d = %+v, want %+v", i+1, lines[i], sends[i])
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// internal/service/smtp_sender.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP security modes
+const (
+	SMTPSecurityStartTLS = "starttls" // Plain connection upgraded with STARTTLS (usually port 587)
+	SMTPSecurityTLS      = "tls"      // Implicit TLS from the first byte (usually port 465)
+	SMTPSecurityNone     = "none"     // No encryption, only for local SMTP stand-ins
+)
+
+// SMTPConfig holds SMTP sender configuration
+type SMTPConfig struct {
+	Host               string
+	Port               int
+	Username           string
+	Password           string
+	Security           string
+	Timeout            time.Duration
+	InsecureSkipVerify bool
+	// HeloName is the name sent in EHLO, defaults to "localhost"
+	HeloName string
+}
+
+// Implementation of the EmailSender interface backed by an SMTP server
+type smtpSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender creates a new SMTP based email sender
+func NewSMTPSender(config SMTPConfig) (EmailSender, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	if config.Port <= 0 {
+		return nil, errors.New("SMTP port is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.HeloName == "" {
+		config.HeloName = "localhost"
+	}
+
+	switch config.Security {
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	case "":
+		config.Security = SMTPSecurityStartTLS
+	default:
+		return nil, fmt.Errorf("unsupported SMTP security mode: %s", config.Security)
+	}
+
+	return &smtpSender{
+		config: config,
+	}, nil
+}
+
+// Send delivers a message through the configured SMTP server
+func (s *smtpSender) Send(ctx context.Context, msg *EmailMessage) error {
+	body, err := buildMessage(msg)
+	if err != nil {
+		return &EmailDeliveryError{Stage: "build", Err: err}
+	}
+
+	// Bound the whole SMTP conversation by the timeout and the caller's deadline
+	deadline := time.Now().Add(s.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return &EmailDeliveryError{Stage: "connect", Temporary: true, Err: err}
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return &EmailDeliveryError{Stage: "connect", Temporary: true, Err: err}
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return classifySMTPError("greeting", err)
+	}
+	defer client.Close()
+
+	if err := client.Hello(s.config.HeloName); err != nil {
+		return classifySMTPError("hello", err)
+	}
+
+	if s.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return &EmailDeliveryError{Stage: "starttls", Err: errors.New("server does not support STARTTLS")}
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return classifySMTPError("starttls", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return &EmailDeliveryError{Stage: "auth", Err: errors.New("server does not support AUTH")}
+		}
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return classifySMTPError("auth", err)
+		}
+	}
+
+	if err := client.Mail(msg.From); err != nil {
+		return classifySMTPError("mail_from", err)
+	}
+
+	for _, rcpt := range msg.To {
+		if err := client.Rcpt(rcpt); err != nil {
+			return classifySMTPError("rcpt_to", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return classifySMTPError("data", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return classifySMTPError("data", err)
+	}
+	if err := w.Close(); err != nil {
+		return classifySMTPError("data", err)
+	}
+
+	// A failed QUIT doesn't mean the message wasn't accepted
+	_ = client.Quit()
+
+	return nil
+}
+
+// dial opens the TCP (or implicit TLS) connection to the SMTP server
+func (s *smtpSender) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+
+	if s.config.Security == SMTPSecurityTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (s *smtpSender) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName:         s.config.Host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.config.InsecureSkipVerify,
+	}
+}
+
+// classifySMTPError wraps an SMTP error and decides if retrying could help.
+// 4xx replies and network errors are temporary, 5xx replies are permanent.
+func classifySMTPError(stage string, err error) error {
+	deliveryErr := &EmailDeliveryError{Stage: stage, Err: err}
+
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		deliveryErr.Code = protoErr.Code
+		deliveryErr.Temporary = protoErr.Code >= 400 && protoErr.Code < 500
+		return deliveryErr
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		deliveryErr.Temporary = true
+	}
+
+	return deliveryErr
+}
+
+// buildMessage renders the RFC 5322 message including headers
+func buildMessage(msg *EmailMessage) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+
+	from := (&mail.Address{Name: msg.FromName, Address: msg.From}).String()
+	to := make([]string, 0, len(msg.To))
+	for _, rcpt := range msg.To {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", rcpt, err)
+		}
+		to = append(to, addr.String())
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", strings.Join(to, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID(msg.From))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	// Text-only mails keep a single part, otherwise offer both versions
+	// and let the client pick (the last part is the preferred one)
+	if msg.HTMLBody == "" {
+		writeHeader(&buf, "Content-Type", "text/plain; charset=UTF-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.TextBody); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", msg.TextBody},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+// messageID generates a unique Message-ID using the sender's domain
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
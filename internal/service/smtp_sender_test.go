@@ -0,0 +1,338 @@
+// internal/service/smtp_sender_test.go
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server for the sender tests. It speaks just
+// enough of RFC 5321 for net/smtp and records what it received.
+type smtpStandIn struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool              // Advertise and accept STARTTLS
+	replies   map[string]string // Reply overrides by command, e.g. "RCPT" -> "550 no such user"
+
+	mu       sync.Mutex
+	received smtpReceived
+}
+
+// smtpReceived is what the stand-in saw in one conversation
+type smtpReceived struct {
+	TLS      bool // Whether the message was sent over TLS
+	Auth     string
+	MailFrom string
+	RcptTo   []string
+	Data     string
+}
+
+// newSMTPStandIn starts a stand-in on a loopback port. With implicitTLS the
+// connection is TLS from the first byte.
+func newSMTPStandIn(t *testing.T, implicitTLS, startTLS bool, replies map[string]string) *smtpStandIn {
+	t.Helper()
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if implicitTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &smtpStandIn{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		startTLS:  startTLS,
+		replies:   replies,
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, implicitTLS)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) result() smtpReceived {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received
+}
+
+func (s *smtpStandIn) serve(conn net.Conn, secure bool) {
+	defer func() { conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	var got smtpReceived
+
+	reply("220 stand-in ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		if override, ok := s.replies[verb]; ok {
+			reply(override)
+			continue
+		}
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-stand-in")
+			if s.startTLS && !secure {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 go ahead")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			r = bufio.NewReader(conn)
+		case "AUTH":
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+				got.Auth = string(decoded)
+			}
+			reply("235 authenticated")
+		case "MAIL":
+			got.MailFrom = strings.TrimPrefix(line, "MAIL FROM:")
+			reply("250 ok")
+		case "RCPT":
+			got.RcptTo = append(got.RcptTo, strings.TrimPrefix(line, "RCPT TO:"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 send the message")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			got.Data = data.String()
+			got.TLS = secure
+			s.mu.Lock()
+			s.received = got
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// selfSignedCertificate makes a certificate for 127.0.0.1, the sender
+// skips verification in these tests
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func testEmailMessage() *EmailMessage {
+	return &EmailMessage{
+		From:     "noreply@example.com",
+		FromName: "Qubool Kallyaanam",
+		To:       []string{"user@example.com"},
+		Subject:  "Your code",
+		TextBody: "Your code is 123456",
+		HTMLBody: "<p>Your code is <b>123456</b></p>",
+	}
+}
+
+func TestSMTPSenderSecurityModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		security    string
+		implicitTLS bool
+		startTLS    bool
+		username    string
+		wantTLS     bool
+	}{
+		{name: "starttls", security: SMTPSecurityStartTLS, startTLS: true, username: "mailer", wantTLS: true},
+		{name: "implicit tls", security: SMTPSecurityTLS, implicitTLS: true, username: "mailer", wantTLS: true},
+		{name: "none", security: SMTPSecurityNone},
+		{name: "none with auth on loopback", security: SMTPSecurityNone, username: "mailer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPStandIn(t, tt.implicitTLS, tt.startTLS, nil)
+
+			sender, err := NewSMTPSender(SMTPConfig{
+				Host:               "127.0.0.1",
+				Port:               server.port(),
+				Username:           tt.username,
+				Password:           "secret",
+				Security:           tt.security,
+				Timeout:            5 * time.Second,
+				InsecureSkipVerify: true,
+			})
+			if err != nil {
+				t.Fatalf("NewSMTPSender() error = %v", err)
+			}
+
+			if err := sender.Send(context.Background(), testEmailMessage()); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			got := server.result()
+			if got.TLS != tt.wantTLS {
+				t.Errorf("sent over TLS = %v, want %v", got.TLS, tt.wantTLS)
+			}
+			if got.MailFrom != "<noreply@example.com>" {
+				t.Errorf("MAIL FROM = %q", got.MailFrom)
+			}
+			if len(got.RcptTo) != 1 || got.RcptTo[0] != "<user@example.com>" {
+				t.Errorf("RCPT TO = %q", got.RcptTo)
+			}
+			if wantAuth := "\x00mailer\x00secret"; tt.username != "" && got.Auth != wantAuth {
+				t.Errorf("AUTH PLAIN = %q, want %q", got.Auth, wantAuth)
+			}
+			for _, want := range []string{"Subject: Your code", "multipart/alternative", "Your code is 123456"} {
+				if !strings.Contains(got.Data, want) {
+					t.Errorf("message is missing %q:\n%s", want, got.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		security      string
+		startTLS      bool
+		replies       map[string]string
+		wantStage     string
+		wantCode      int
+		wantTemporary bool
+	}{
+		{
+			name:      "server without starttls",
+			security:  SMTPSecurityStartTLS,
+			wantStage: "starttls",
+		},
+		{
+			name:      "rejected recipient",
+			security:  SMTPSecurityNone,
+			replies:   map[string]string{"RCPT": "550 no such user"},
+			wantStage: "rcpt_to",
+			wantCode:  550,
+		},
+		{
+			name:          "greylisted sender",
+			security:      SMTPSecurityNone,
+			replies:       map[string]string{"MAIL": "451 try again later"},
+			wantStage:     "mail_from",
+			wantCode:      451,
+			wantTemporary: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPStandIn(t, false, tt.startTLS, tt.replies)
+
+			sender, err := NewSMTPSender(SMTPConfig{
+				Host:     "127.0.0.1",
+				Port:     server.port(),
+				Security: tt.security,
+				Timeout:  5 * time.Second,
+			})
+			if err != nil {
+				t.Fatalf("NewSMTPSender() error = %v", err)
+			}
+
+			err = sender.Send(context.Background(), testEmailMessage())
+			var deliveryErr *EmailDeliveryError
+			if !errors.As(err, &deliveryErr) {
+				t.Fatalf("Send() error = %v, want an EmailDeliveryError", err)
+			}
+			if deliveryErr.Stage != tt.wantStage || deliveryErr.Code != tt.wantCode || deliveryErr.Temporary != tt.wantTemporary {
+				t.Errorf("Send() error = %+v, want stage %s, code %d, temporary %v",
+					deliveryErr, tt.wantStage, tt.wantCode, tt.wantTemporary)
+			}
+		})
+	}
+}
+
+func TestSMTPSenderConnectionRefused(t *testing.T) {
+	// Grab a free port and close it again so nothing listens there
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: port, Security: SMTPSecurityNone, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewSMTPSender() error = %v", err)
+	}
+
+	err = sender.Send(context.Background(), testEmailMessage())
+	var deliveryErr *EmailDeliveryError
+	if !errors.As(err, &deliveryErr) || deliveryErr.Stage != "connect" || !deliveryErr.Temporary {
+		t.Errorf("Send() error = %v, want a temporary connect failure", err)
+	}
+}
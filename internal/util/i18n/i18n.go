@@ -0,0 +1,200 @@
+// internal/util/i18n/i18n.go
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// ContextKey is the context key holding the negotiated locale
+const ContextKey = "locale"
+
+// DefaultLocale is used when no supported locale is requested and as the
+// fallback for messages missing from a catalog
+const DefaultLocale = "en"
+
+// SupportedLocales lists the locales with a message catalog, DefaultLocale first
+var SupportedLocales = []string{"en", "ml", "ar"}
+
+// rtlLocales are written right-to-left
+var rtlLocales = map[string]bool{
+	"ar": true,
+}
+
+//go:embed locales/*.json
+var embeddedCatalogs embed.FS
+
+var matcher = language.NewMatcher([]language.Tag{
+	language.English,
+	language.Malayalam,
+	language.Arabic,
+})
+
+// Catalog holds messages keyed by locale and stable message ID
+type Catalog struct {
+	messages map[string]map[string]string
+}
+
+var (
+	mu             sync.RWMutex
+	defaultCatalog = mustLoadEmbedded()
+)
+
+func mustLoadEmbedded() *Catalog {
+	catalog, err := load(embeddedCatalogs, "locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to load embedded catalogs: %v", err))
+	}
+	return catalog
+}
+
+// LoadOverrides merges <locale>.json files from dir over the embedded
+// catalogs, so copy can be changed without a rebuild
+func LoadOverrides(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	overrides, err := load(os.DirFS(dir), ".")
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	merged := &Catalog{messages: make(map[string]map[string]string)}
+	for locale, messages := range defaultCatalog.messages {
+		merged.messages[locale] = make(map[string]string, len(messages))
+		for id, msg := range messages {
+			merged.messages[locale][id] = msg
+		}
+	}
+	for locale, messages := range overrides.messages {
+		if merged.messages[locale] == nil {
+			merged.messages[locale] = make(map[string]string, len(messages))
+		}
+		for id, msg := range messages {
+			merged.messages[locale][id] = msg
+		}
+	}
+
+	defaultCatalog = merged
+	return nil
+}
+
+func load(fsys fs.FS, dir string) (*Catalog, error) {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{messages: make(map[string]map[string]string, len(files))}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid catalog %s: %w", file, err)
+		}
+
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		catalog.messages[locale] = messages
+	}
+
+	return catalog, nil
+}
+
+// Translate returns the message for id in the given locale, falling back to
+// the default locale and finally to the ID itself. Arguments are applied with
+// fmt verbs, use explicit indexes (%[1]v) so translations can reorder them.
+func Translate(locale, id string, args ...interface{}) string {
+	mu.RLock()
+	catalog := defaultCatalog
+	mu.RUnlock()
+
+	msg, ok := catalog.messages[locale][id]
+	if !ok || msg == "" {
+		msg, ok = catalog.messages[DefaultLocale][id]
+		if !ok {
+			return id
+		}
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// T translates id using the locale stored in the context
+func T(ctx context.Context, id string, args ...interface{}) string {
+	return Translate(LocaleFromContext(ctx), id, args...)
+}
+
+// LocaleFromContext returns the locale stored in the context or the default
+func LocaleFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultLocale
+	}
+	if locale, ok := ctx.Value(ContextKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// WithLocale returns a copy of ctx carrying the given locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, ContextKey, locale)
+}
+
+// Negotiate picks the best supported locale for an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLocale
+	}
+
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+	if locale := Normalize(base.String()); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Normalize returns the supported locale matching the given language tag,
+// or an empty string if it isn't supported
+func Normalize(locale string) string {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return ""
+	}
+
+	base, _ := tag.Base()
+	for _, supported := range SupportedLocales {
+		if base.String() == supported {
+			return supported
+		}
+	}
+	return ""
+}
+
+// Direction returns the text direction ("ltr" or "rtl") for a locale
+func Direction(locale string) string {
+	if rtlLocales[locale] {
+		return "rtl"
+	}
+	return "ltr"
+}
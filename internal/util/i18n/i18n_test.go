@@ -0,0 +1,146 @@
+// internal/util/i18n/i18n_test.go
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "no header", acceptLanguage: "", want: "en"},
+		{name: "malayalam", acceptLanguage: "ml", want: "ml"},
+		{name: "regional arabic", acceptLanguage: "ar-SA,ar;q=0.9", want: "ar"},
+		{name: "quality order", acceptLanguage: "fr;q=0.9,ml;q=0.8,en;q=0.1", want: "ml"},
+		{name: "unsupported", acceptLanguage: "fr-FR", want: "en"},
+		{name: "malformed", acceptLanguage: ";;;", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Negotiate(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "en", want: "en"},
+		{locale: "en-GB", want: "en"},
+		{locale: "ML", want: "ml"},
+		{locale: "ar-EG", want: "ar"},
+		{locale: "fr", want: ""},
+		{locale: "", want: ""},
+		{locale: "not a tag", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := Normalize(tt.locale); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	mu.Lock()
+	saved := defaultCatalog
+	defaultCatalog = &Catalog{messages: map[string]map[string]string{
+		"en": {"greeting": "Hello %[1]v", "bye": "Goodbye"},
+		"ml": {"greeting": "നമസ്കാരം %[1]v", "bye": ""},
+	}}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		defaultCatalog = saved
+		mu.Unlock()
+	})
+
+	tests := []struct {
+		name   string
+		locale string
+		id     string
+		args   []interface{}
+		want   string
+	}{
+		{name: "translated", locale: "ml", id: "greeting", args: []interface{}{"Amal"}, want: "നമസ്കാരം Amal"},
+		{name: "empty translation falls back", locale: "ml", id: "bye", want: "Goodbye"},
+		{name: "unknown locale falls back", locale: "ar", id: "greeting", args: []interface{}{"Amal"}, want: "Hello Amal"},
+		{name: "unknown message", locale: "en", id: "missing.id", want: "missing.id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Translate(tt.locale, tt.id, tt.args...); got != tt.want {
+				t.Errorf("Translate(%q, %q) = %q, want %q", tt.locale, tt.id, got, tt.want)
+			}
+		})
+	}
+
+	if got := T(WithLocale(context.Background(), "ml"), "greeting", "Amal"); got != "നമസ്കാരം Amal" {
+		t.Errorf("T() with a ml context = %q", got)
+	}
+	if got := LocaleFromContext(context.Background()); got != DefaultLocale {
+		t.Errorf("LocaleFromContext() without a locale = %q, want %q", got, DefaultLocale)
+	}
+}
+
+// Every catalog must translate every message with the same arguments
+func TestEmbeddedCatalogsMatch(t *testing.T) {
+	verbs := regexp.MustCompile(`%\[\d+\][a-z]`)
+	argumentsOf := func(msg string) string {
+		seen := make(map[string]bool)
+		var found []string
+		for _, verb := range verbs.FindAllString(msg, -1) {
+			if !seen[verb] {
+				seen[verb] = true
+				found = append(found, verb)
+			}
+		}
+		sort.Strings(found)
+		return strings.Join(found, " ")
+	}
+
+	reference := defaultCatalog.messages[DefaultLocale]
+	for _, locale := range SupportedLocales[1:] {
+		t.Run(locale, func(t *testing.T) {
+			messages := defaultCatalog.messages[locale]
+			for id, msg := range reference {
+				translated, ok := messages[id]
+				if !ok || translated == "" {
+					t.Errorf("%s is not translated", id)
+					continue
+				}
+				if want, got := argumentsOf(msg), argumentsOf(translated); got != want {
+					t.Errorf("%s uses %q, want %q", id, got, want)
+				}
+			}
+			for id := range messages {
+				if _, ok := reference[id]; !ok {
+					t.Errorf("%s is not in the %s catalog", id, DefaultLocale)
+				}
+			}
+		})
+	}
+}
+
+func TestDirection(t *testing.T) {
+	for locale, want := range map[string]string{"en": "ltr", "ml": "ltr", "ar": "rtl"} {
+		if got := Direction(locale); got != want {
+			t.Errorf("Direction(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
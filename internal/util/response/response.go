@@ -49,6 +49,22 @@ func Error(c *gin.Context, statusCode int, message string, err error) {
 	})
 }
 
+// ErrorWithDetails returns an error response that also carries data the
+// client can act on, e.g. how long to wait before retrying
+func ErrorWithDetails(c *gin.Context, statusCode int, message string, err error, data interface{}) {
+	errorMessage := ""
+	if err != nil {
+		errorMessage = err.Error()
+	}
+
+	c.JSON(statusCode, StandardResponse{
+		Status:  statusCode,
+		Message: message,
+		Data:    data,
+		Error:   errorMessage,
+	})
+}
+
 // BadRequest returns a 400 bad request error with standard format
 func BadRequest(c *gin.Context, message string, err error) {
 	Error(c, http.StatusBadRequest, message, err)
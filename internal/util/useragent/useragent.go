@@ -0,0 +1,120 @@
+// internal/util/useragent/useragent.go
+package useragent
+
+import "strings"
+
+// Device types
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// Info is what a User-Agent header says about the client. It is only meant
+// for showing sessions to their owner and spotting a token moving to a
+// different kind of client, not for anything a client couldn't fake.
+type Info struct {
+	Browser string // e.g. Chrome, Safari, our mobile app
+	OS      string // e.g. Android, iOS, Windows
+	Device  string // One of the Device* constants
+}
+
+// Family identifies the kind of client, browser and OS without versions,
+// which stays the same across updates
+func (i Info) Family() string {
+	return i.Browser + "/" + i.OS
+}
+
+// String describes the client for people, e.g. "Chrome on Android"
+func (i Info) String() string {
+	switch {
+	case i.Browser == "Unknown" && i.OS == "Unknown":
+		return "Unknown device"
+	case i.OS == "Unknown":
+		return i.Browser
+	case i.Browser == "Unknown":
+		return i.OS + " device"
+	}
+	return i.Browser + " on " + i.OS
+}
+
+// Browser tokens in the order they must be checked: several browsers also
+// send the tokens of the ones they are built on, e.g. Edge sends Chrome and
+// Safari, Chrome sends Safari
+var browsers = []struct {
+	token string
+	name  string
+}{
+	{"okhttp/", "Android app"},
+	{"cfnetwork/", "iOS app"},
+	{"edg/", "Edge"},
+	{"edga/", "Edge"},
+	{"edgios/", "Edge"},
+	{"opr/", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"safari/", "Safari"},
+	{"curl/", "curl"},
+	{"postmanruntime/", "Postman"},
+}
+
+// OS tokens, checked in order for the same reason: Android sends Linux,
+// iOS sends "like Mac OS X"
+var operatingSystems = []struct {
+	token string
+	name  string
+}{
+	{"android", "Android"},
+	{"iphone", "iOS"},
+	{"ipad", "iPadOS"},
+	{"ipod", "iOS"},
+	{"cros", "ChromeOS"},
+	{"windows", "Windows"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// Parse reads a User-Agent header
+func Parse(userAgent string) Info {
+	ua := strings.ToLower(userAgent)
+	info := Info{Browser: "Unknown", OS: "Unknown", Device: DeviceUnknown}
+
+	if ua == "" {
+		return info
+	}
+
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			info.Browser = b.name
+			break
+		}
+	}
+
+	for _, os := range operatingSystems {
+		if strings.Contains(ua, os.token) {
+			info.OS = os.name
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "bot") || strings.Contains(ua, "spider") || strings.Contains(ua, "crawler"):
+		info.Device = DeviceBot
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(info.OS == "Android" && !strings.Contains(ua, "mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "mobile") || strings.Contains(ua, "iphone") || info.OS == "Android" ||
+		info.Browser == "iOS app" || info.Browser == "Android app":
+		info.Device = DeviceMobile
+	case info.OS == "Windows" || info.OS == "macOS" || info.OS == "Linux" || info.OS == "ChromeOS":
+		info.Device = DeviceDesktop
+	}
+
+	return info
+}
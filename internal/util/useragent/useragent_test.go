@@ -0,0 +1,100 @@
+// internal/util/useragent/useragent_test.go
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		userAgent  string
+		want       Info
+		wantString string
+	}{
+		{
+			name:       "Chrome on Android phone",
+			userAgent:  "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			want:       Info{Browser: "Chrome", OS: "Android", Device: DeviceMobile},
+			wantString: "Chrome on Android",
+		},
+		{
+			name:       "Chrome on Android tablet",
+			userAgent:  "Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want:       Info{Browser: "Chrome", OS: "Android", Device: DeviceTablet},
+			wantString: "Chrome on Android",
+		},
+		{
+			name:       "Safari on iPhone",
+			userAgent:  "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want:       Info{Browser: "Safari", OS: "iOS", Device: DeviceMobile},
+			wantString: "Safari on iOS",
+		},
+		{
+			name:       "Safari on iPad",
+			userAgent:  "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want:       Info{Browser: "Safari", OS: "iPadOS", Device: DeviceTablet},
+			wantString: "Safari on iPadOS",
+		},
+		{
+			name:       "Edge on Windows",
+			userAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want:       Info{Browser: "Edge", OS: "Windows", Device: DeviceDesktop},
+			wantString: "Edge on Windows",
+		},
+		{
+			name:       "Firefox on macOS",
+			userAgent:  "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.5; rv:127.0) Gecko/20100101 Firefox/127.0",
+			want:       Info{Browser: "Firefox", OS: "macOS", Device: DeviceDesktop},
+			wantString: "Firefox on macOS",
+		},
+		{
+			name:       "Android app",
+			userAgent:  "okhttp/4.12.0",
+			want:       Info{Browser: "Android app", OS: "Unknown", Device: DeviceMobile},
+			wantString: "Android app",
+		},
+		{
+			name:       "crawler",
+			userAgent:  "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want:       Info{Browser: "Unknown", OS: "Unknown", Device: DeviceBot},
+			wantString: "Unknown device",
+		},
+		{
+			name:       "curl",
+			userAgent:  "curl/8.5.0",
+			want:       Info{Browser: "curl", OS: "Unknown", Device: DeviceUnknown},
+			wantString: "curl",
+		},
+		{
+			name:       "empty",
+			userAgent:  "",
+			want:       Info{Browser: "Unknown", OS: "Unknown", Device: DeviceUnknown},
+			wantString: "Unknown device",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.userAgent)
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+// Updates change versions, not the family
+func TestInfoFamily(t *testing.T) {
+	before := Parse("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36")
+	after := Parse("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36")
+	other := Parse("Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0")
+
+	if before.Family() != after.Family() {
+		t.Errorf("Family() changed with the browser version: %q, %q", before.Family(), after.Family())
+	}
+	if before.Family() == other.Family() {
+		t.Errorf("Family() of Chrome and Firefox are both %q", before.Family())
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
+ALTER TABLE pending_registrations DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_password_history_user_created;
+DROP TABLE IF EXISTS password_history;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS password_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Lookups and pruning always walk one user's entries newest first
+CREATE INDEX idx_password_history_user_created ON password_history(user_id, created_at DESC);